@@ -11,6 +11,12 @@ import (
 
 // Aes256Encrypt key must be 32 bytes long to have AES-256
 func Aes256Encrypt(key []byte, plaintext []byte) ([]byte, error) {
+	return Aes256EncryptWithAD(key, plaintext, nil)
+}
+
+// Aes256EncryptWithAD encrypts plaintext and authenticates it together with associatedData (not encrypted
+// and not part of the output). The same associatedData is required for decryption
+func Aes256EncryptWithAD(key []byte, plaintext []byte, associatedData []byte) ([]byte, error) {
 
 	if len(key) != 32 {
 		return nil, errors.New("Key must be 32 bytes long")
@@ -30,10 +36,15 @@ func Aes256Encrypt(key []byte, plaintext []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	return aesgcm.Seal(nonce, nonce, plaintext, nil), nil
+	return aesgcm.Seal(nonce, nonce, plaintext, associatedData), nil
 }
 
 func Aes256Decrypt(key []byte, ciphertext []byte) ([]byte, error) {
+	return Aes256DecryptWithAD(key, ciphertext, nil)
+}
+
+// Aes256DecryptWithAD decrypts ciphertext created by Aes256EncryptWithAD with the same associatedData
+func Aes256DecryptWithAD(key []byte, ciphertext []byte, associatedData []byte) ([]byte, error) {
 	c, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
@@ -50,7 +61,7 @@ func Aes256Decrypt(key []byte, ciphertext []byte) ([]byte, error) {
 	}
 
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	return gcm.Open(nil, nonce, ciphertext, nil)
+	return gcm.Open(nil, nonce, ciphertext, associatedData)
 }
 
 func ConfigEncodeAesKey(key []byte) string {
@@ -39,3 +39,27 @@ func TestEncodeDecodeAesKey(t *testing.T) {
 	}
 	assert.Equal(t, decoded, key)
 }
+
+func TestEncDecAes256WithAD(t *testing.T) {
+	key, err := New32ByteKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("This should be encrypted")
+	ciphertext, err := Aes256EncryptWithAD(key, msg, []byte("mailbox-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := Aes256DecryptWithAD(key, ciphertext, []byte("mailbox-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, plaintext, msg)
+
+	_, err = Aes256DecryptWithAD(key, ciphertext, []byte("mailbox-2"))
+	assert.Error(t, err)
+	_, err = Aes256Decrypt(key, ciphertext)
+	assert.Error(t, err)
+}
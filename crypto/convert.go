@@ -0,0 +1,96 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+)
+
+// curve25519P is the field prime 2^255 - 19
+var curve25519P, _ = new(big.Int).SetString("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed", 16)
+
+// Ed25519PrivateKeyToCurve25519 converts ed25519 signing key to X25519 encryption key (same as libsodium
+// crypto_sign_ed25519_sk_to_curve25519): clamped first half of SHA-512 of the seed
+func Ed25519PrivateKeyToCurve25519(k PrivKey) (PrivCKey, error) {
+	edk, ok := k.(*Ed25519PrivateKey)
+	if !ok {
+		return nil, ErrBadKeyType
+	}
+	h := sha512.Sum512(edk.k.Seed())
+	var key [32]byte
+	copy(key[:], h[:32])
+	key[0] &= 248
+	key[31] &= 127
+	key[31] |= 64
+	return &Curve25519PrivateKey{Key: &key}, nil
+}
+
+// Ed25519PublicKeyToCurve25519 converts ed25519 public key to X25519 public key with the birational
+// map u = (1 + y) / (1 - y) (same as libsodium crypto_sign_ed25519_pk_to_curve25519)
+func Ed25519PublicKeyToCurve25519(k PubKey) (PubCKey, error) {
+	edk, ok := k.(*Ed25519PublicKey)
+	if !ok {
+		return nil, ErrBadKeyType
+	}
+	if len(edk.k) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 public key size")
+	}
+	// y is little endian with the sign of x in the top bit
+	le := make([]byte, 32)
+	copy(le, edk.k)
+	le[31] &= 0x7f
+	y := new(big.Int).SetBytes(reverse(le))
+	if y.Cmp(curve25519P) >= 0 {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+
+	one := big.NewInt(1)
+	num := new(big.Int).Add(one, y)
+	den := new(big.Int).Sub(one, y)
+	den.Mod(den, curve25519P)
+	if den.Sign() == 0 {
+		return nil, errors.New("invalid ed25519 public key")
+	}
+	den.ModInverse(den, curve25519P)
+	u := num.Mul(num, den)
+	u.Mod(u, curve25519P)
+
+	var key [32]byte
+	ub := u.Bytes()
+	copy(key[32-len(ub):], ub)
+	reverseInPlace(key[:])
+	return &Curve25519PublicKey{Key: &key}, nil
+}
+
+// NewKeysFromSeed deterministically derives ed25519 signing and X25519 encryption key pairs from a 32 byte seed
+func NewKeysFromSeed(seed []byte) (PrivKey, PubKey, PrivCKey, PubCKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, nil, nil, nil, errors.New("seed must be 32 bytes long")
+	}
+	priv := &Ed25519PrivateKey{k: ed25519.NewKeyFromSeed(seed)}
+	pub := priv.GetPublic()
+	privC, err := Ed25519PrivateKeyToCurve25519(priv)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	pubC, err := Ed25519PublicKeyToCurve25519(pub)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return priv, pub, privC, pubC, nil
+}
+
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[len(b)-1-i]
+	}
+	return out
+}
+
+func reverseInPlace(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/tj/assert"
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestEd25519ToCurve25519(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		priv, pub, err := GenerateEd25519Key(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privC, err := Ed25519PrivateKeyToCurve25519(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubC, err := Ed25519PublicKeyToCurve25519(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		derived, err := curve25519.X25519(privC.Raw()[:], curve25519.Basepoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, derived, pubC.Raw()[:])
+	}
+}
+
+func TestNewKeysFromSeed(t *testing.T) {
+	// RFC 8032 section 7.1 test 1
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	priv, pub, privC, pubC, err := NewKeysFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawPub, err := pub.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a", hex.EncodeToString(rawPub))
+	assert.True(t, priv.GetPublic().Equals(pub))
+	assert.Equal(t, "307c83864f2833cb427a2ef1c00a013cfdff2768d980c0a3a520f006904de94f", hex.EncodeToString(privC.Raw()[:]))
+	assert.Equal(t, "d85e07ec22b0ad881537c2f44d662d1a143cf830c57aca4305d85c7a90f6b62e", hex.EncodeToString(pubC.Raw()[:]))
+
+	// derived encryption keys work with box
+	otherPriv, otherPub, err := GenerateCryptKeys(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("encrypted with keys derived from the signing seed")
+	encrypted, err := privC.Encrypt(otherPub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := otherPriv.Decrypt(pubC, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, msg, decrypted)
+
+	_, _, _, _, err = NewKeysFromSeed(seed[:16])
+	assert.Error(t, err)
+}
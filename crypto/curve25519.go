@@ -2,10 +2,13 @@ package crypto
 
 import (
 	crypto_rand "crypto/rand"
+	"crypto/sha256"
 	"io"
 	"regexp"
 
 	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
 	"golang.org/x/crypto/nacl/box"
 	"golang.org/x/crypto/nacl/secretbox"
 )
@@ -34,6 +37,9 @@ func (k *Curve25519PrivateKey) Encrypt(recipientPublicKey PubCKey, payload []byt
 }
 
 func (k *Curve25519PrivateKey) Decrypt(senderPublicKey PubCKey, encryptedPayload []byte) ([]byte, error) {
+	if len(encryptedPayload) < 24 {
+		return nil, ErrDecryptionFailed
+	}
 	var nonce [24]byte
 	copy(nonce[:], encryptedPayload[:24])
 	plain, ok := box.Open(nil, encryptedPayload[24:], &nonce, senderPublicKey.Raw(), k.Key)
@@ -43,6 +49,96 @@ func (k *Curve25519PrivateKey) Decrypt(senderPublicKey PubCKey, encryptedPayload
 	return plain, nil
 }
 
+// EncryptWithAD encrypts payload for the recipient and binds it to associatedData (e.g. mailbox or message ID),
+// which is not encrypted and must be provided again for decryption. The secretbox key is HMAC-SHA256 of
+// associatedData keyed by the box shared key, so ciphertexts are not compatible with Encrypt.
+// Works with any PrivCKey implementation (only Raw is used)
+func EncryptWithAD(priv PrivCKey, recipientPublicKey PubCKey, payload, associatedData []byte) ([]byte, error) {
+	return boxSealWithAD(PrecomputeSharedKey(priv, recipientPublicKey)[:], payload, associatedData)
+}
+
+// DecryptWithAD decrypts payload created by EncryptWithAD with the same associatedData
+func DecryptWithAD(priv PrivCKey, senderPublicKey PubCKey, encryptedPayload, associatedData []byte) ([]byte, error) {
+	return boxOpenWithAD(PrecomputeSharedKey(priv, senderPublicKey)[:], encryptedPayload, associatedData)
+}
+
+// EncryptWithAD is the same as EncryptWithAD(k, recipientPublicKey, payload, associatedData)
+func (k *Curve25519PrivateKey) EncryptWithAD(recipientPublicKey PubCKey, payload, associatedData []byte) ([]byte, error) {
+	return EncryptWithAD(k, recipientPublicKey, payload, associatedData)
+}
+
+// DecryptWithAD is the same as DecryptWithAD(k, senderPublicKey, encryptedPayload, associatedData)
+func (k *Curve25519PrivateKey) DecryptWithAD(senderPublicKey PubCKey, encryptedPayload, associatedData []byte) ([]byte, error) {
+	return DecryptWithAD(k, senderPublicKey, encryptedPayload, associatedData)
+}
+
+// EncryptWithSharedKey encrypts payload with box shared key from PrecomputeSharedKey (box.SealAfterPrecomputation).
+// Output is the same as Encrypt, without the X25519 scalar multiplication on every call
+func EncryptWithSharedKey(sharedKey *[32]byte, payload []byte) ([]byte, error) {
+	nonce, err := Nonce()
+	if err != nil {
+		return nil, err
+	}
+	return box.SealAfterPrecomputation(nonce[:], payload, &nonce, sharedKey), nil
+}
+
+// DecryptWithSharedKey decrypts payload created by Encrypt or EncryptWithSharedKey with box shared key
+func DecryptWithSharedKey(sharedKey *[32]byte, encryptedPayload []byte) ([]byte, error) {
+	if len(encryptedPayload) < 24 {
+		return nil, ErrDecryptionFailed
+	}
+	var nonce [24]byte
+	copy(nonce[:], encryptedPayload[:24])
+	plain, ok := box.OpenAfterPrecomputation(nil, encryptedPayload[24:], &nonce, sharedKey)
+	if !ok {
+		return nil, ErrDecryptionFailed
+	}
+	return plain, nil
+}
+
+// SealAnonymous encrypts msg to the recipient with an ephemeral key pair, the sender needs no keys and stays
+// anonymous. Output is ephemeral public key || box (libsodium crypto_box_seal compatible)
+func SealAnonymous(recipientPublicKey PubCKey, msg []byte) ([]byte, error) {
+	return box.SealAnonymous(nil, msg, recipientPublicKey.Raw(), crypto_rand.Reader)
+}
+
+// OpenAnonymous decrypts message sealed with SealAnonymous to the public key of priv.
+// Works with any PrivCKey implementation (only Raw is used)
+func OpenAnonymous(priv PrivCKey, sealed []byte) ([]byte, error) {
+	pub, err := publicKeyOf(priv)
+	if err != nil {
+		return nil, err
+	}
+	plain, ok := box.OpenAnonymous(nil, sealed, pub.Raw(), priv.Raw())
+	if !ok {
+		return nil, ErrDecryptionFailed
+	}
+	return plain, nil
+}
+
+// OpenAnonymous is the same as OpenAnonymous(k, sealed)
+func (k *Curve25519PrivateKey) OpenAnonymous(sealed []byte) ([]byte, error) {
+	return OpenAnonymous(k, sealed)
+}
+
+// publicKeyOf returns X25519 public key of the private key
+func publicKeyOf(priv PrivCKey) (PubCKey, error) {
+	pub, err := curve25519.X25519(priv.Raw()[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], pub)
+	return &Curve25519PublicKey{Key: &key}, nil
+}
+
+// PrecomputeSharedKey returns NaCl box shared key of priv and the peer public key (box.Precompute)
+func PrecomputeSharedKey(priv PrivCKey, peer PubCKey) *[32]byte {
+	var shared [32]byte
+	box.Precompute(&shared, peer.Raw(), priv.Raw())
+	return &shared
+}
+
 func (k *Curve25519PrivateKey) Raw() *[32]byte {
 	return k.Key
 }
@@ -104,3 +200,17 @@ func New32ByteKey() ([]byte, error) {
 	}
 	return nonce, nil
 }
+
+// Curve25519SharedKey returns 32 byte key agreed between priv and the peer public key (X25519 and HKDF-SHA256).
+// Both sides get the same key, e.g. for NewAes256EncryptWriter
+func Curve25519SharedKey(priv PrivCKey, peer PubCKey) ([]byte, error) {
+	shared, err := curve25519.X25519(priv.Raw()[:], peer.Raw()[:])
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, nil, []byte("mcrypt curve25519 shared key")), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
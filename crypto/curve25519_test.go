@@ -44,3 +44,126 @@ func TestEncryptDecrypt(t *testing.T) {
 	}
 	assert.Equal(t, decrypted, msg)
 }
+
+func TestEncryptDecryptWithAD(t *testing.T) {
+	alicePriv, alicePub, _ := GenerateCryptKeys(rand.Reader)
+	bobPriv, bobPub, _ := GenerateCryptKeys(rand.Reader)
+	msg := []byte("encrypted mailbox value")
+
+	encrypted, err := EncryptWithAD(alicePriv, bobPub, msg, []byte("mailbox-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := DecryptWithAD(bobPriv, alicePub, encrypted, []byte("mailbox-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+
+	// value moved to another row
+	_, err = DecryptWithAD(bobPriv, alicePub, encrypted, []byte("mailbox-2"))
+	assert.Equal(t, ErrDecryptionFailed, err)
+	_, err = bobPriv.Decrypt(alicePub, encrypted)
+	assert.Equal(t, ErrDecryptionFailed, err)
+	_, err = DecryptWithAD(bobPriv, alicePub, encrypted[:10], []byte("mailbox-1"))
+	assert.Equal(t, ErrDecryptionFailed, err)
+}
+
+// rawCKey implements only the core PrivCKey methods
+type rawCKey struct {
+	PrivCKey
+}
+
+func TestWithADCustomPrivCKey(t *testing.T) {
+	priv, pub, _ := GenerateCryptKeys(rand.Reader)
+	custom := rawCKey{priv}
+	msg := []byte("external key implementation")
+
+	encrypted, err := EncryptWithAD(custom, pub, msg, []byte("mailbox-1"))
+	assert.NoError(t, err)
+	decrypted, err := DecryptWithAD(priv, pub, encrypted, []byte("mailbox-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+
+	sealed, err := SealAnonymous(pub, msg)
+	assert.NoError(t, err)
+	opened, err := OpenAnonymous(custom, sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, opened)
+}
+
+func TestSealAnonymous(t *testing.T) {
+	priv, pub, _ := GenerateCryptKeys(rand.Reader)
+	msg := []byte("from a browser without keys")
+
+	sealed, err := SealAnonymous(pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 32+len(msg)+16, len(sealed))
+	opened, err := OpenAnonymous(priv, sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, opened)
+
+	otherPriv, _, _ := GenerateCryptKeys(rand.Reader)
+	_, err = OpenAnonymous(otherPriv, sealed)
+	assert.Equal(t, ErrDecryptionFailed, err)
+	sealed[40] ^= 1
+	_, err = OpenAnonymous(priv, sealed)
+	assert.Equal(t, ErrDecryptionFailed, err)
+	_, err = OpenAnonymous(priv, sealed[:20])
+	assert.Equal(t, ErrDecryptionFailed, err)
+}
+
+func TestEncryptWithSharedKey(t *testing.T) {
+	alicePriv, alicePub, err := GenerateCryptKeys(rand.Reader)
+	assert.NoError(t, err)
+	bobPriv, bobPub, err := GenerateCryptKeys(rand.Reader)
+	assert.NoError(t, err)
+
+	aliceShared := PrecomputeSharedKey(alicePriv, bobPub)
+	bobShared := PrecomputeSharedKey(bobPriv, alicePub)
+	assert.Equal(t, aliceShared, bobShared)
+
+	// interoperable with Encrypt and Decrypt
+	encrypted, err := EncryptWithSharedKey(aliceShared, []byte("precomputed"))
+	assert.NoError(t, err)
+	plain, err := bobPriv.Decrypt(alicePub, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "precomputed", string(plain))
+
+	encrypted, err = alicePriv.Encrypt(bobPub, []byte("regular"))
+	assert.NoError(t, err)
+	plain, err = DecryptWithSharedKey(bobShared, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "regular", string(plain))
+
+	encrypted[len(encrypted)-1] ^= 1
+	_, err = DecryptWithSharedKey(bobShared, encrypted)
+	assert.Equal(t, ErrDecryptionFailed, err)
+	_, err = DecryptWithSharedKey(bobShared, encrypted[:10])
+	assert.Equal(t, ErrDecryptionFailed, err)
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	priv, _, _ := GenerateCryptKeys(rand.Reader)
+	_, peer, _ := GenerateCryptKeys(rand.Reader)
+	msg := make([]byte, 1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := priv.Encrypt(peer, msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncryptWithSharedKey(b *testing.B) {
+	priv, _, _ := GenerateCryptKeys(rand.Reader)
+	_, peer, _ := GenerateCryptKeys(rand.Reader)
+	sharedKey := PrecomputeSharedKey(priv, peer)
+	msg := make([]byte, 1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := EncryptWithSharedKey(sharedKey, msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
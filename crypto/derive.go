@@ -0,0 +1,137 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// HardenedKeyStart is the first hardened child index (SLIP-0010 ed25519 supports only hardened derivation)
+const HardenedKeyStart uint32 = 0x80000000
+
+// ErrInvalidDerivationPath is returned for empty or malformed derivation paths
+var ErrInvalidDerivationPath = errors.New("invalid derivation path")
+
+// HDKey is a SLIP-0010 ed25519 extended private key (key and chain code)
+type HDKey struct {
+	key       [32]byte
+	chainCode [32]byte
+}
+
+func newHDKey(hmacKey, data []byte) *HDKey {
+	mac := hmac.New(sha512.New, hmacKey)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	k := &HDKey{}
+	copy(k.key[:], sum[:32])
+	copy(k.chainCode[:], sum[32:])
+	return k
+}
+
+// NewHDMasterKey creates SLIP-0010 ed25519 master key from a 16 to 64 byte seed
+func NewHDMasterKey(seed []byte) (*HDKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, errors.New("seed must be between 16 and 64 bytes long")
+	}
+	return newHDKey([]byte("ed25519 seed"), seed), nil
+}
+
+// NewHDMasterKeyFromEd25519 creates master key with the seed of ed25519 private key
+func NewHDMasterKeyFromEd25519(k PrivKey) (*HDKey, error) {
+	edk, ok := k.(*Ed25519PrivateKey)
+	if !ok {
+		return nil, ErrBadKeyType
+	}
+	if len(edk.k) != 64 {
+		return nil, errors.New("invalid ed25519 private key size")
+	}
+	return NewHDMasterKey(edk.k.Seed())
+}
+
+// Child derives hardened child key with SLIP-0010 (index must be HardenedKeyStart or above)
+func (k *HDKey) Child(index uint32) (*HDKey, error) {
+	if index < HardenedKeyStart {
+		return nil, errors.New("ed25519 supports only hardened derivation")
+	}
+	data := make([]byte, 1+32+4)
+	copy(data[1:], k.key[:])
+	binary.BigEndian.PutUint32(data[33:], index)
+	return newHDKey(k.chainCode[:], data), nil
+}
+
+// NamedChild derives hardened child key for a name (e.g. user ID). Same as SLIP-0010 child derivation
+// with the name in place of the index and a 0x01 prefix so names never collide with numeric indexes
+func (k *HDKey) NamedChild(name string) (*HDKey, error) {
+	if name == "" {
+		return nil, ErrInvalidDerivationPath
+	}
+	data := make([]byte, 0, 1+32+len(name))
+	data = append(data, 0x01)
+	data = append(data, k.key[:]...)
+	data = append(data, name...)
+	return newHDKey(k.chainCode[:], data), nil
+}
+
+// DerivePath derives key for a slash separated path such as users/<id>/mail or m/44'/0'.
+// Numeric segments ending with ' or H are SLIP-0010 hardened indexes (0' and 0H are the same index), all other
+// segments are names. Numeric segments with more than one marker (1H') or out of range indexes are rejected
+func (k *HDKey) DerivePath(path string) (*HDKey, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 0 && segments[0] == "m" {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 {
+		return nil, ErrInvalidDerivationPath
+	}
+	current := k
+	for _, segment := range segments {
+		index, ok, err := parseHardenedIndex(segment)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			current, err = current.Child(HardenedKeyStart + index)
+		} else {
+			current, err = current.NamedChild(segment)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return current, nil
+}
+
+// parseHardenedIndex returns index of a hardened segment (digits followed by ' or H). Segments that aren't
+// digits followed by markers are names
+func parseHardenedIndex(segment string) (uint32, bool, error) {
+	digits := strings.TrimRight(segment, "'H")
+	if digits == "" || digits == segment || strings.Trim(digits, "0123456789") != "" {
+		return 0, false, nil
+	}
+	if len(segment)-len(digits) != 1 {
+		return 0, false, ErrInvalidDerivationPath
+	}
+	index, err := strconv.ParseUint(digits, 10, 31)
+	if err != nil {
+		return 0, false, ErrInvalidDerivationPath
+	}
+	return uint32(index), true, nil
+}
+
+// Seed returns the 32 byte private key (ed25519 seed) of the extended key
+func (k *HDKey) Seed() []byte {
+	return append([]byte(nil), k.key[:]...)
+}
+
+// ChainCode returns the 32 byte chain code of the extended key
+func (k *HDKey) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode[:]...)
+}
+
+// Keys returns ed25519 signing and X25519 encryption key pairs of the extended key (see NewKeysFromSeed)
+func (k *HDKey) Keys() (PrivKey, PubKey, PrivCKey, PubCKey, error) {
+	return NewKeysFromSeed(k.key[:])
+}
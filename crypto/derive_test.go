@@ -0,0 +1,82 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestHDKeySLIP0010Vectors(t *testing.T) {
+	// SLIP-0010 test vector 1 for ed25519
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewHDMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb", hex.EncodeToString(master.ChainCode()))
+	assert.Equal(t, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7", hex.EncodeToString(master.Seed()))
+
+	child, err := master.DerivePath("m/0'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "8b59aa11380b624e81507a27fedda59fea6d0b779a778918a2fd3590e16e9c69", hex.EncodeToString(child.ChainCode()))
+	assert.Equal(t, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3", hex.EncodeToString(child.Seed()))
+	_, pub, _, _, err := child.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawPub, _ := pub.Raw()
+	assert.Equal(t, "8c8a13df77a28f3445213a0f432fde644acaa215fc72dcdf300d5efaa85d350c", hex.EncodeToString(rawPub))
+
+	_, err = master.Child(0)
+	assert.Error(t, err)
+}
+
+func TestHDKeyNamedPath(t *testing.T) {
+	master, err := NewHDMasterKey([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k1, err := master.DerivePath("users/alice/mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := master.DerivePath("/users/alice/mail/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, k1.Seed(), k2.Seed())
+
+	other, err := master.DerivePath("users/bob/mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, k1.Seed(), other.Seed())
+
+	// step by step derivation gives the same key
+	users, _ := master.NamedChild("users")
+	alice, _ := users.NamedChild("alice")
+	mail, _ := alice.NamedChild("mail")
+	assert.Equal(t, k1.Seed(), mail.Seed())
+
+	_, err = master.DerivePath("users//mail")
+	assert.Equal(t, ErrInvalidDerivationPath, err)
+	_, err = master.DerivePath("m")
+	assert.Equal(t, ErrInvalidDerivationPath, err)
+
+	// both hardened markers are the same index, stacked markers and out of range indexes are rejected
+	h1, err := master.DerivePath("m/0'/1'")
+	assert.NoError(t, err)
+	h2, err := master.DerivePath("m/0H/1H")
+	assert.NoError(t, err)
+	assert.Equal(t, h1.Seed(), h2.Seed())
+	for _, path := range []string{"m/1H'", "m/1''", "m/2147483648'"} {
+		_, err = master.DerivePath(path)
+		assert.Equal(t, ErrInvalidDerivationPath, err, path)
+	}
+	// names ending with a marker stay names
+	_, err = master.DerivePath("users/JOHN'/mail")
+	assert.NoError(t, err)
+}
@@ -2,13 +2,30 @@ package crypto
 
 import (
 	"bytes"
+	stdcrypto "crypto"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"errors"
 	"fmt"
 	"io"
 
 	pb "github.com/igorrendulic/mcrypt-sdk-go/proto"
 	"google.golang.org/protobuf/proto"
+)
+
+// Signature contexts separating signatures of different protocols (RFC 8032 Ed25519ctx, Ed25519ph).
+// A signature created with one context doesn't verify with another one or as a plain ed25519 signature
+const (
+	SignContextHandshake = "mcrypt handshake v1"
+	SignContextKeyConfig = "mcrypt key config v1"
+	SignContextMessage   = "mcrypt message v1"
+)
 
-	"golang.org/x/crypto/ed25519"
+var (
+	// ErrInvalidSignContext is returned for empty (Ed25519ctx) or longer than 255 bytes contexts
+	ErrInvalidSignContext = errors.New("signature context must be 1 to 255 bytes long")
+	// ErrInvalidDigest is returned when prehashed signing input is not a SHA-512 digest
+	ErrInvalidDigest = errors.New("digest must be a 64 byte SHA-512 hash")
 )
 
 // Ed25519PrivateKey is an ed25519 private key
@@ -76,6 +93,26 @@ func (k *Ed25519PrivateKey) Sign(msg []byte) ([]byte, error) {
 	return ed25519.Sign(k.k, msg), nil
 }
 
+// SignWithContext returns Ed25519ctx signature of msg bound to non-empty context (e.g. SignContextHandshake)
+func (k *Ed25519PrivateKey) SignWithContext(msg []byte, context string) ([]byte, error) {
+	if len(context) == 0 || len(context) > 255 {
+		return nil, ErrInvalidSignContext
+	}
+	return k.k.Sign(nil, msg, &ed25519.Options{Context: context})
+}
+
+// SignPrehashed returns Ed25519ph signature of digest (SHA-512 of the message, see Ed25519Prehash) bound to
+// an optional context. Large inputs can be hashed while streaming instead of being held in memory
+func (k *Ed25519PrivateKey) SignPrehashed(digest []byte, context string) ([]byte, error) {
+	if len(context) > 255 {
+		return nil, ErrInvalidSignContext
+	}
+	if len(digest) != sha512.Size {
+		return nil, ErrInvalidDigest
+	}
+	return k.k.Sign(nil, digest, &ed25519.Options{Hash: stdcrypto.SHA512, Context: context})
+}
+
 func (k *Ed25519PublicKey) Type() pb.KeyType {
 	return pb.KeyType_Ed25519
 }
@@ -104,6 +141,34 @@ func (k *Ed25519PublicKey) Verify(data []byte, sig []byte) (bool, error) {
 	return ed25519.Verify(k.k, data, sig), nil
 }
 
+// VerifyWithContext checks Ed25519ctx signature against the input data and context
+func (k *Ed25519PublicKey) VerifyWithContext(data []byte, sig []byte, context string) (bool, error) {
+	if len(context) == 0 || len(context) > 255 {
+		return false, ErrInvalidSignContext
+	}
+	return ed25519.VerifyWithOptions(k.k, data, sig, &ed25519.Options{Context: context}) == nil, nil
+}
+
+// VerifyPrehashed checks Ed25519ph signature against the SHA-512 digest of the data and context
+func (k *Ed25519PublicKey) VerifyPrehashed(digest []byte, sig []byte, context string) (bool, error) {
+	if len(context) > 255 {
+		return false, ErrInvalidSignContext
+	}
+	if len(digest) != sha512.Size {
+		return false, ErrInvalidDigest
+	}
+	return ed25519.VerifyWithOptions(k.k, digest, sig, &ed25519.Options{Hash: stdcrypto.SHA512, Context: context}) == nil, nil
+}
+
+// Ed25519Prehash returns SHA-512 digest of everything read from r for SignPrehashed and VerifyPrehashed
+func Ed25519Prehash(r io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
 func UnmarshalEd25519PublicKey(data []byte) (PubKey, error) {
 	if len(data) != 32 {
 		return nil, fmt.Errorf("expect ed25519 public key data size to be 32")
@@ -124,7 +189,17 @@ func UnmarshalEd25519PrivateKey(keyBytes []byte) (PrivKey, error) {
 		return nil, fmt.Errorf("Proto unmarshaling failed")
 	}
 
+	data := privKey.GetData()
+	if len(data) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("expect ed25519 private key data size to be %d", ed25519.PrivateKeySize)
+	}
+	// private key is seed || public key, both halves must belong together
+	expected := ed25519.NewKeyFromSeed(data[:ed25519.SeedSize])
+	if !bytes.Equal(expected, data) {
+		return nil, fmt.Errorf("ed25519 private key doesn't match its public key")
+	}
+
 	return &Ed25519PrivateKey{
-		k: ed25519.PrivateKey(privKey.GetData()),
+		k: ed25519.PrivateKey(data),
 	}, nil
 }
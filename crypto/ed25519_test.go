@@ -1,8 +1,11 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/rand"
+	"crypto/sha512"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -90,3 +93,110 @@ func TestEncodeDecode(t *testing.T) {
 		t.Fatal("keys are not equal")
 	}
 }
+
+func TestUnmarshalInvalidKeys(t *testing.T) {
+	priv, _, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := priv.Raw()
+	// public half of the private key replaced
+	raw[63] ^= 1
+	privBytes, err := MarshalPrivateKey(&Ed25519PrivateKey{k: raw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalEd25519PrivateKey(privBytes); err == nil {
+		t.Fatal("expected error for inconsistent private key")
+	}
+	shortBytes, err := MarshalPrivateKey(&Ed25519PrivateKey{k: raw[:32]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalEd25519PrivateKey(shortBytes); err == nil {
+		t.Fatal("expected error for short private key")
+	}
+	if _, err := ConfigDecodeEncryptKey(ConfigEncodeKey([]byte("short"))); err == nil {
+		t.Fatal("expected error for short encryption key")
+	}
+}
+
+func TestED25519SignWithContext(t *testing.T) {
+	privKey, pubKey, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, pub := privKey.(*Ed25519PrivateKey), pubKey.(*Ed25519PublicKey)
+	data := []byte("user@mail.io")
+
+	sig, err := priv.SignWithContext(data, SignContextHandshake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := pub.VerifyWithContext(data, sig, SignContextHandshake); err != nil || !ok {
+		t.Fatal("context signature didn't verify", err)
+	}
+	if ok, _ := pub.VerifyWithContext(data, sig, SignContextMessage); ok {
+		t.Fatal("signature verified with another context")
+	}
+	if ok, _ := pub.Verify(data, sig); ok {
+		t.Fatal("context signature verified as plain ed25519 signature")
+	}
+	plain, _ := priv.Sign(data)
+	if ok, _ := pub.VerifyWithContext(data, plain, SignContextHandshake); ok {
+		t.Fatal("plain ed25519 signature verified with context")
+	}
+
+	if _, err := priv.SignWithContext(data, ""); err != ErrInvalidSignContext {
+		t.Fatal("expected ErrInvalidSignContext for empty context, got", err)
+	}
+	if _, err := pub.VerifyWithContext(data, sig, strings.Repeat("x", 256)); err != ErrInvalidSignContext {
+		t.Fatal("expected ErrInvalidSignContext for long context, got", err)
+	}
+}
+
+func TestED25519SignPrehashed(t *testing.T) {
+	privKey, pubKey, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// optional extension interfaces of PrivKey/PubKey
+	priv, ok := privKey.(ContextSigner)
+	if !ok {
+		t.Fatal("ed25519 private key should implement ContextSigner")
+	}
+	pub, ok := pubKey.(ContextVerifier)
+	if !ok {
+		t.Fatal("ed25519 public key should implement ContextVerifier")
+	}
+	data := bytes.Repeat([]byte("large attachment "), 10000)
+
+	digest, err := Ed25519Prehash(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha512.Sum512(data)
+	if !bytes.Equal(digest, sum[:]) {
+		t.Fatal("prehash isn't SHA-512 of the data")
+	}
+
+	for _, context := range []string{"", SignContextKeyConfig} {
+		sig, err := priv.SignPrehashed(digest, context)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := pub.VerifyPrehashed(digest, sig, context); err != nil || !ok {
+			t.Fatal("prehashed signature didn't verify", err)
+		}
+		if ok, _ := pub.VerifyPrehashed(digest, sig, SignContextMessage); ok {
+			t.Fatal("prehashed signature verified with another context")
+		}
+		if ok, _ := pubKey.Verify(digest, sig); ok {
+			t.Fatal("prehashed signature verified as plain ed25519 signature of the digest")
+		}
+	}
+
+	if _, err := priv.SignPrehashed(data[:32], ""); err != ErrInvalidDigest {
+		t.Fatal("expected ErrInvalidDigest, got", err)
+	}
+}
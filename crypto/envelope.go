@@ -0,0 +1,175 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// Envelope format: magic "MCE" | version | flags | recipient count (uint16) | recipient stanzas | payload.
+// Stanza is the data key sealed to the recipient (SealAnonymous), prefixed by the recipient key ID when
+// recipients are revealed. Payload is AES-256-GCM with the data key, the header and stanzas are authenticated
+const (
+	envelopeVersion         = 1
+	envelopeFlagRecipients  = 1
+	envelopeFixedHeaderSize = 3 + 1 + 1 + 2
+	envelopeRecipientIDSize = 8
+	envelopeWrappedKeySize  = 32 + box.Overhead + 32
+	envelopeMaxRecipients   = 1<<16 - 1
+)
+
+var envelopeMagic = []byte("MCE")
+
+var (
+	// ErrInvalidEnvelope is returned for malformed envelopes
+	ErrInvalidEnvelope = errors.New("invalid envelope")
+	// ErrNotRecipient is returned when the private key can't open any recipient stanza
+	ErrNotRecipient = errors.New("not a recipient of the envelope")
+	// ErrRecipientsHidden is returned by EnvelopeRecipients for envelopes sealed without revealed recipients
+	ErrRecipientsHidden = errors.New("envelope recipients are hidden")
+)
+
+// EnvelopeOptions of SealEnvelopeWithOptions
+type EnvelopeOptions struct {
+	// RevealRecipients stores recipient key IDs in the envelope so recipients find their stanza directly
+	// and EnvelopeRecipients can list them. By default recipients try every stanza and the list stays hidden
+	RevealRecipients bool
+}
+
+// X25519KeyID returns hex encoded first 8 bytes of SHA-256 of the public key (same as mcrypt key IDs)
+func X25519KeyID(pub PubCKey) string {
+	return hex.EncodeToString(x25519KeyID(pub))
+}
+
+func x25519KeyID(pub PubCKey) []byte {
+	h := sha256.Sum256(pub.Raw()[:])
+	return h[:envelopeRecipientIDSize]
+}
+
+// SealEnvelope encrypts plaintext once with a random data key and wraps the data key for each recipient.
+// Any single recipient opens it with OpenEnvelope. associatedData is authenticated but not encrypted
+func SealEnvelope(recipients []PubCKey, plaintext, associatedData []byte) ([]byte, error) {
+	return SealEnvelopeWithOptions(recipients, plaintext, associatedData, EnvelopeOptions{})
+}
+
+// SealEnvelopeWithOptions is SealEnvelope with options
+func SealEnvelopeWithOptions(recipients []PubCKey, plaintext, associatedData []byte, opts EnvelopeOptions) ([]byte, error) {
+	if len(recipients) == 0 || len(recipients) > envelopeMaxRecipients {
+		return nil, errors.New("envelope requires between 1 and 65535 recipients")
+	}
+	dataKey, err := New32ByteKey()
+	if err != nil {
+		return nil, err
+	}
+	stanzaSize := envelopeWrappedKeySize
+	var flags byte
+	if opts.RevealRecipients {
+		flags |= envelopeFlagRecipients
+		stanzaSize += envelopeRecipientIDSize
+	}
+	header := make([]byte, envelopeFixedHeaderSize, envelopeFixedHeaderSize+len(recipients)*stanzaSize)
+	copy(header, envelopeMagic)
+	header[3] = envelopeVersion
+	header[4] = flags
+	binary.BigEndian.PutUint16(header[5:], uint16(len(recipients)))
+	for _, recipient := range recipients {
+		if opts.RevealRecipients {
+			header = append(header, x25519KeyID(recipient)...)
+		}
+		wrapped, err := SealAnonymous(recipient, dataKey)
+		if err != nil {
+			return nil, err
+		}
+		header = append(header, wrapped...)
+	}
+	payload, err := Aes256EncryptWithAD(dataKey, plaintext, append(append([]byte(nil), header...), associatedData...))
+	if err != nil {
+		return nil, err
+	}
+	return append(header, payload...), nil
+}
+
+type envelopeStanza struct {
+	recipientID []byte
+	wrappedKey  []byte
+}
+
+func parseEnvelope(envelope []byte) (stanzas []envelopeStanza, headerSize int, err error) {
+	if len(envelope) < envelopeFixedHeaderSize || string(envelope[:3]) != string(envelopeMagic) || envelope[3] != envelopeVersion {
+		return nil, 0, ErrInvalidEnvelope
+	}
+	revealed := envelope[4]&envelopeFlagRecipients != 0
+	count := int(binary.BigEndian.Uint16(envelope[5:]))
+	stanzaSize := envelopeWrappedKeySize
+	if revealed {
+		stanzaSize += envelopeRecipientIDSize
+	}
+	headerSize = envelopeFixedHeaderSize + count*stanzaSize
+	if count == 0 || len(envelope) < headerSize {
+		return nil, 0, ErrInvalidEnvelope
+	}
+	stanzas = make([]envelopeStanza, count)
+	offset := envelopeFixedHeaderSize
+	for i := range stanzas {
+		if revealed {
+			stanzas[i].recipientID = envelope[offset : offset+envelopeRecipientIDSize]
+			offset += envelopeRecipientIDSize
+		}
+		stanzas[i].wrappedKey = envelope[offset : offset+envelopeWrappedKeySize]
+		offset += envelopeWrappedKeySize
+	}
+	return stanzas, headerSize, nil
+}
+
+// OpenEnvelope decrypts envelope created by SealEnvelope with the recipient private key
+func OpenEnvelope(priv PrivCKey, envelope, associatedData []byte) ([]byte, error) {
+	stanzas, headerSize, err := parseEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+	var ownID []byte
+	for _, stanza := range stanzas {
+		if stanza.recipientID != nil {
+			if ownID == nil {
+				pub, err := publicKeyOf(priv)
+				if err != nil {
+					return nil, err
+				}
+				ownID = x25519KeyID(pub)
+			}
+			if string(stanza.recipientID) != string(ownID) {
+				continue
+			}
+		}
+		dataKey, err := OpenAnonymous(priv, stanza.wrappedKey)
+		if err != nil {
+			continue
+		}
+		ad := append(append([]byte(nil), envelope[:headerSize]...), associatedData...)
+		plain, err := Aes256DecryptWithAD(dataKey, envelope[headerSize:], ad)
+		if err != nil {
+			return nil, ErrDecryptionFailed
+		}
+		return plain, nil
+	}
+	return nil, ErrNotRecipient
+}
+
+// EnvelopeRecipients returns recipient key IDs (X25519KeyID) of envelope sealed with RevealRecipients
+func EnvelopeRecipients(envelope []byte) ([]string, error) {
+	stanzas, _, err := parseEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(stanzas))
+	for i, stanza := range stanzas {
+		if stanza.recipientID == nil {
+			return nil, ErrRecipientsHidden
+		}
+		ids[i] = hex.EncodeToString(stanza.recipientID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,75 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	var privs []PrivCKey
+	var pubs []PubCKey
+	for i := 0; i < 10; i++ {
+		priv, pub, err := GenerateCryptKeys(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs = append(privs, priv)
+		pubs = append(pubs, pub)
+	}
+	body := make([]byte, 4096)
+	rand.Read(body)
+
+	envelope, err := SealEnvelope(pubs, body, []byte("message-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// body is encrypted once
+	assert.True(t, len(envelope) < len(body)+10*(envelopeWrappedKeySize+1)+100)
+	for _, priv := range privs {
+		plain, err := OpenEnvelope(priv, envelope, []byte("message-1"))
+		assert.NoError(t, err)
+		assert.Equal(t, body, plain)
+	}
+	_, err = EnvelopeRecipients(envelope)
+	assert.Equal(t, ErrRecipientsHidden, err)
+
+	outsider, _, _ := GenerateCryptKeys(rand.Reader)
+	_, err = OpenEnvelope(outsider, envelope, []byte("message-1"))
+	assert.Equal(t, ErrNotRecipient, err)
+	_, err = OpenEnvelope(privs[0], envelope, []byte("message-2"))
+	assert.Equal(t, ErrDecryptionFailed, err)
+
+	// stanzas are authenticated
+	modified := append([]byte(nil), envelope...)
+	modified[envelopeFixedHeaderSize+envelopeWrappedKeySize+5] ^= 1
+	_, err = OpenEnvelope(privs[0], modified, []byte("message-1"))
+	assert.Equal(t, ErrDecryptionFailed, err)
+
+	_, err = OpenEnvelope(privs[0], envelope[:20], nil)
+	assert.Equal(t, ErrInvalidEnvelope, err)
+	_, err = SealEnvelope(nil, body, nil)
+	assert.Error(t, err)
+}
+
+func TestEnvelopeRevealRecipients(t *testing.T) {
+	priv1, pub1, _ := GenerateCryptKeys(rand.Reader)
+	priv2, pub2, _ := GenerateCryptKeys(rand.Reader)
+	msg := []byte("mail body")
+
+	envelope, err := SealEnvelopeWithOptions([]PubCKey{pub1, pub2}, msg, nil, EnvelopeOptions{RevealRecipients: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := EnvelopeRecipients(envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{X25519KeyID(pub1), X25519KeyID(pub2)}, ids)
+
+	plain, err := OpenEnvelope(priv2, envelope, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, plain)
+	plain, err = OpenEnvelope(priv1, envelope, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, plain)
+}
@@ -0,0 +1,214 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// JWK key type and curves for Ed25519 and X25519 keys (RFC 8037)
+const (
+	JWKKeyTypeOKP   = "OKP"
+	JWKCurveEd25519 = "Ed25519"
+	JWKCurveX25519  = "X25519"
+)
+
+// JWK is a JSON Web Key (RFC 7517) of an octet key pair (RFC 8037)
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	D   string `json:"d,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+var jwkEncoding = base64.RawURLEncoding
+
+func newOKPJWK(crv string, x, d []byte) (*JWK, error) {
+	jwk := &JWK{
+		Kty: JWKKeyTypeOKP,
+		Crv: crv,
+		X:   jwkEncoding.EncodeToString(x),
+	}
+	if d != nil {
+		jwk.D = jwkEncoding.EncodeToString(d)
+	}
+	kid, err := jwk.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	jwk.Kid = kid
+	return jwk, nil
+}
+
+// Ed25519PublicKeyToJWK encodes ed25519 public key as JWK (kid is the RFC 7638 thumbprint)
+func Ed25519PublicKeyToJWK(k PubKey) (*JWK, error) {
+	edk, ok := k.(*Ed25519PublicKey)
+	if !ok {
+		return nil, ErrBadKeyType
+	}
+	jwk, err := newOKPJWK(JWKCurveEd25519, edk.k, nil)
+	if err != nil {
+		return nil, err
+	}
+	jwk.Use = "sig"
+	jwk.Alg = "EdDSA"
+	return jwk, nil
+}
+
+// Ed25519PrivateKeyToJWK encodes ed25519 private key as JWK (d is the 32 byte seed)
+func Ed25519PrivateKeyToJWK(k PrivKey) (*JWK, error) {
+	edk, ok := k.(*Ed25519PrivateKey)
+	if !ok {
+		return nil, ErrBadKeyType
+	}
+	jwk, err := newOKPJWK(JWKCurveEd25519, edk.pubKeyBytes(), edk.k.Seed())
+	if err != nil {
+		return nil, err
+	}
+	jwk.Use = "sig"
+	jwk.Alg = "EdDSA"
+	return jwk, nil
+}
+
+// X25519PublicKeyToJWK encodes curve25519 encryption public key as JWK
+func X25519PublicKeyToJWK(k PubCKey) (*JWK, error) {
+	jwk, err := newOKPJWK(JWKCurveX25519, k.Raw()[:], nil)
+	if err != nil {
+		return nil, err
+	}
+	jwk.Use = "enc"
+	return jwk, nil
+}
+
+// X25519PrivateKeyToJWK encodes curve25519 encryption private key as JWK
+func X25519PrivateKeyToJWK(k PrivCKey) (*JWK, error) {
+	pub, err := curve25519.X25519(k.Raw()[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := newOKPJWK(JWKCurveX25519, pub, k.Raw()[:])
+	if err != nil {
+		return nil, err
+	}
+	jwk.Use = "enc"
+	return jwk, nil
+}
+
+// UnmarshalJWK parses JSON encoded JWK
+func UnmarshalJWK(data []byte) (*JWK, error) {
+	var jwk JWK
+	err := json.Unmarshal(data, &jwk)
+	if err != nil {
+		return nil, err
+	}
+	return &jwk, nil
+}
+
+// Thumbprint returns base64url encoded RFC 7638 SHA-256 thumbprint of the key
+func (j *JWK) Thumbprint() (string, error) {
+	if j.Kty != JWKKeyTypeOKP {
+		return "", ErrBadKeyType
+	}
+	// required members only, in lexicographic order (RFC 7638 section 3.2)
+	canonical, err := json.Marshal(struct {
+		Crv string `json:"crv"`
+		Kty string `json:"kty"`
+		X   string `json:"x"`
+	}{j.Crv, j.Kty, j.X})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return jwkEncoding.EncodeToString(sum[:]), nil
+}
+
+func (j *JWK) decode(crv string, private bool) (x, d []byte, err error) {
+	if j.Kty != JWKKeyTypeOKP || j.Crv != crv {
+		return nil, nil, ErrBadKeyType
+	}
+	x, err = jwkEncoding.DecodeString(j.X)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid JWK x: %w", err)
+	}
+	if len(x) != 32 {
+		return nil, nil, errors.New("invalid JWK x size")
+	}
+	if !private {
+		return x, nil, nil
+	}
+	if j.D == "" {
+		return nil, nil, errors.New("JWK has no private key")
+	}
+	d, err = jwkEncoding.DecodeString(j.D)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid JWK d: %w", err)
+	}
+	if len(d) != 32 {
+		return nil, nil, errors.New("invalid JWK d size")
+	}
+	return x, d, nil
+}
+
+// Ed25519PublicKey decodes ed25519 public key from JWK
+func (j *JWK) Ed25519PublicKey() (PubKey, error) {
+	x, _, err := j.decode(JWKCurveEd25519, false)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalEd25519PublicKey(x)
+}
+
+// Ed25519PrivateKey decodes ed25519 private key from JWK and checks it matches the public key x
+func (j *JWK) Ed25519PrivateKey() (PrivKey, error) {
+	x, d, err := j.decode(JWKCurveEd25519, true)
+	if err != nil {
+		return nil, err
+	}
+	priv := &Ed25519PrivateKey{k: ed25519.NewKeyFromSeed(d)}
+	if string(priv.pubKeyBytes()) != string(x) {
+		return nil, errors.New("JWK private key doesn't match public key")
+	}
+	return priv, nil
+}
+
+// X25519PublicKey decodes curve25519 encryption public key from JWK
+func (j *JWK) X25519PublicKey() (PubCKey, error) {
+	x, _, err := j.decode(JWKCurveX25519, false)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], x)
+	return &Curve25519PublicKey{Key: &key}, nil
+}
+
+// X25519PrivateKey decodes curve25519 encryption private key from JWK and checks it matches the public key x
+func (j *JWK) X25519PrivateKey() (PrivCKey, error) {
+	x, d, err := j.decode(JWKCurveX25519, true)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := curve25519.X25519(d, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	if string(pub) != string(x) {
+		return nil, errors.New("JWK private key doesn't match public key")
+	}
+	var key [32]byte
+	copy(key[:], d)
+	return &Curve25519PrivateKey{Key: &key}, nil
+}
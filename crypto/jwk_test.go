@@ -0,0 +1,103 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+// RFC 8037 appendix A.1 and A.3
+const (
+	rfc8037Ed25519JWK        = `{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	rfc8037Ed25519Thumbprint = "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+)
+
+func TestEd25519JWK(t *testing.T) {
+	jwk, err := UnmarshalJWK([]byte(rfc8037Ed25519JWK))
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumbprint, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, rfc8037Ed25519Thumbprint, thumbprint)
+
+	priv, err := jwk.Ed25519PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := jwk.Ed25519PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, priv.GetPublic().Equals(pub))
+
+	privJWK, err := Ed25519PrivateKeyToJWK(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, jwk.D, privJWK.D)
+	assert.Equal(t, jwk.X, privJWK.X)
+	assert.Equal(t, rfc8037Ed25519Thumbprint, privJWK.Kid)
+
+	pubJWK, err := Ed25519PublicKeyToJWK(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "", pubJWK.D)
+	assert.Equal(t, rfc8037Ed25519Thumbprint, pubJWK.Kid)
+
+	_, err = pubJWK.Ed25519PrivateKey()
+	assert.Error(t, err)
+	_, err = pubJWK.X25519PublicKey()
+	assert.Equal(t, ErrBadKeyType, err)
+}
+
+func TestX25519JWK(t *testing.T) {
+	// RFC 7748 section 6.1 (Bob), RFC 8037 appendix A.6
+	d, _ := hex.DecodeString("5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb")
+	var key [32]byte
+	copy(key[:], d)
+	jwk, err := X25519PrivateKeyToJWK(&Curve25519PrivateKey{Key: &key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "3p7bfXt9wbTTW2HC7OQ1Nz-DQ8hbeGdNrfx-FG-IK08", jwk.X)
+
+	jwkJSON, err := json.Marshal(jwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnmarshalJWK(jwkJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := decoded.X25519PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, key[:], priv.Raw()[:])
+
+	_, pub, err := GenerateCryptKeys(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubJWK, err := X25519PublicKeyToJWK(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubNew, err := pubJWK.X25519PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, pub.Raw(), pubNew.Raw())
+
+	// mismatching private and public key
+	decoded.X = pubJWK.X
+	_, err = decoded.X25519PrivateKey()
+	assert.Error(t, err)
+}
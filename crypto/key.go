@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 
 	pb "github.com/igorrendulic/mcrypt-sdk-go/proto"
@@ -89,6 +90,26 @@ type PubKey interface {
 	Verify(data []byte, sig []byte) (bool, error)
 }
 
+// ContextSigner is implemented by private keys that support signatures bound to a context string
+// (Ed25519ctx and Ed25519ph). Optional extension of PrivKey, check with a type assertion
+type ContextSigner interface {
+	// SignWithContext signs the bytes bound to a context string (Ed25519ctx)
+	SignWithContext(msg []byte, context string) ([]byte, error)
+
+	// SignPrehashed signs the SHA-512 digest of a message bound to an optional context string (Ed25519ph)
+	SignPrehashed(digest []byte, context string) ([]byte, error)
+}
+
+// ContextVerifier is implemented by public keys that verify ContextSigner signatures.
+// Optional extension of PubKey, check with a type assertion
+type ContextVerifier interface {
+	// VerifyWithContext verifies signature created by SignWithContext with the same context
+	VerifyWithContext(data []byte, sig []byte, context string) (bool, error)
+
+	// VerifyPrehashed verifies signature created by SignPrehashed over the same digest and context
+	VerifyPrehashed(digest []byte, sig []byte, context string) (bool, error)
+}
+
 // GenSharedKey generates the shared key from a given private key
 type GenSharedKey func([]byte) ([]byte, error)
 
@@ -177,8 +198,11 @@ func ConfigDecodeEncryptKey(b string) (*[32]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if len(decoded) != 32 {
+		return nil, fmt.Errorf("expect encryption key data size to be 32, got %d", len(decoded))
+	}
 	var key [32]byte
-	copy(key[:], decoded[:32])
+	copy(key[:], decoded)
 	return &key, nil
 }
 
@@ -0,0 +1,107 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// BIP-39 English wordlist (sha256 2f5eed53a4727b4bf8880d8f3f199efc90e58503646d9ff8eff3a2ed3b24dbda)
+//
+//go:embed wordlist_english.txt
+var wordlistEnglish string
+
+var (
+	mnemonicWords     = strings.Fields(wordlistEnglish)
+	mnemonicWordIndex = func() map[string]int {
+		index := make(map[string]int, len(mnemonicWords))
+		for i, w := range mnemonicWords {
+			index[w] = i
+		}
+		return index
+	}()
+)
+
+var (
+	// ErrInvalidMnemonic is returned for mnemonics with unknown words or wrong length
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+	// ErrMnemonicChecksum is returned when mnemonic checksum doesn't match (mistyped or missing word)
+	ErrMnemonicChecksum = errors.New("mnemonic checksum mismatch")
+)
+
+// NewMnemonic creates a random 24 word mnemonic (256 bits of entropy)
+func NewMnemonic() (string, error) {
+	entropy, err := New32ByteKey()
+	if err != nil {
+		return "", err
+	}
+	return MnemonicFromEntropy(entropy)
+}
+
+// MnemonicFromEntropy encodes 16, 20, 24, 28 or 32 bytes as a BIP-39 mnemonic (12 to 24 words).
+// The last word carries a checksum of len(entropy)/4 bits of SHA-256 of the entropy
+func MnemonicFromEntropy(entropy []byte) (string, error) {
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", errors.New("entropy must be 16, 20, 24, 28 or 32 bytes long")
+	}
+	checksum := sha256.Sum256(entropy)
+	bits := append(append([]byte(nil), entropy...), checksum[0])
+	wordCount := (len(entropy)*8 + len(entropy)/4) / 11
+
+	words := make([]string, wordCount)
+	for i := 0; i < wordCount; i++ {
+		index := 0
+		for b := i * 11; b < (i+1)*11; b++ {
+			index = index<<1 | int(bits[b/8]>>(7-uint(b%8))&1)
+		}
+		words[i] = mnemonicWords[index]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// EntropyFromMnemonic decodes BIP-39 mnemonic back to its entropy and verifies the checksum
+func EntropyFromMnemonic(mnemonic string) ([]byte, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, ErrInvalidMnemonic
+	}
+	totalBits := len(words) * 11
+	checksumBits := totalBits / 33
+	entropyLen := (totalBits - checksumBits) / 8
+
+	bits := make([]byte, (totalBits+7)/8)
+	for i, w := range words {
+		index, ok := mnemonicWordIndex[w]
+		if !ok {
+			return nil, ErrInvalidMnemonic
+		}
+		for j := 0; j < 11; j++ {
+			if index>>(10-uint(j))&1 == 1 {
+				b := i*11 + j
+				bits[b/8] |= 1 << (7 - uint(b%8))
+			}
+		}
+	}
+	entropy := bits[:entropyLen]
+	checksum := sha256.Sum256(entropy)
+	mask := byte(0xff) << (8 - uint(checksumBits))
+	if bits[entropyLen]&mask != checksum[0]&mask {
+		return nil, ErrMnemonicChecksum
+	}
+	return append([]byte(nil), entropy...), nil
+}
+
+// MnemonicToSeed returns the 64 byte BIP-39 seed (PBKDF2-HMAC-SHA512, 2048 iterations, salt "mnemonic"+passphrase).
+// Mnemonic is validated first. Passphrase is used as is (no unicode normalization)
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	_, err := EntropyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	normalized := strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), 2048, 64, sha512.New), nil
+}
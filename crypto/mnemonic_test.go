@@ -0,0 +1,66 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+// BIP-39 reference vectors (passphrase "TREZOR")
+var bip39Vectors = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth title",
+		"bc09fca1804f7e69da93c2f2028eb238c227f2e9dda30cd63699232578480a4021b146ad717fbb7e451ce9eb835f43620bf5c514db0f8add49f5d121449d3e87",
+	},
+}
+
+func TestMnemonicVectors(t *testing.T) {
+	for _, v := range bip39Vectors {
+		entropy, _ := hex.DecodeString(v.entropy)
+		mnemonic, err := MnemonicFromEntropy(entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, v.mnemonic, mnemonic)
+
+		decoded, err := EntropyFromMnemonic(mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, entropy, decoded)
+
+		seed, err := MnemonicToSeed(mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, v.seed, hex.EncodeToString(seed))
+	}
+}
+
+func TestMnemonicChecksum(t *testing.T) {
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Fields(mnemonic)
+	assert.Equal(t, 24, len(words))
+
+	// last word of the all zero entropy vector is "about", "abandon" fails the checksum
+	_, err = EntropyFromMnemonic(strings.Repeat("abandon ", 12))
+	assert.Equal(t, ErrMnemonicChecksum, err)
+
+	_, err = EntropyFromMnemonic("abandon abandon notaword")
+	assert.Equal(t, ErrInvalidMnemonic, err)
+}
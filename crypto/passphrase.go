@@ -0,0 +1,70 @@
+package crypto
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Argon2idParams are the cost parameters of the Argon2id key derivation
+type Argon2idParams struct {
+	Time    uint32
+	Memory  uint32 // in KiB
+	Threads uint8
+}
+
+// DefaultArgon2idParams are the RFC 9106 second recommended option (64 MiB, 3 passes)
+var DefaultArgon2idParams = Argon2idParams{
+	Time:    3,
+	Memory:  64 * 1024,
+	Threads: 4,
+}
+
+// SaltSize is the size of the random salt used for passphrase key derivation
+const SaltSize = 16
+
+// NewSalt creates random salt for passphrase key derivation
+func NewSalt() ([]byte, error) {
+	salt, err := New32ByteKey()
+	if err != nil {
+		return nil, err
+	}
+	return salt[:SaltSize], nil
+}
+
+// DeriveArgon2idKey derives a 32 byte symmetric key from passphrase and salt
+func DeriveArgon2idKey(passphrase, salt []byte, params Argon2idParams) (*[32]byte, error) {
+	if len(salt) < SaltSize {
+		return nil, errors.New("salt too short")
+	}
+	if params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+	var key [32]byte
+	copy(key[:], argon2.IDKey(passphrase, salt, params.Time, params.Memory, params.Threads, 32))
+	return &key, nil
+}
+
+// SecretboxSeal encrypts and authenticates message with the key. Output is nonce||box
+func SecretboxSeal(key *[32]byte, message []byte) ([]byte, error) {
+	nonce, err := Nonce()
+	if err != nil {
+		return nil, err
+	}
+	return secretbox.Seal(nonce[:], message, &nonce, key), nil
+}
+
+// SecretboxOpen decrypts nonce||box created by SecretboxSeal
+func SecretboxOpen(key *[32]byte, sealed []byte) ([]byte, error) {
+	if len(sealed) < 24+secretbox.Overhead {
+		return nil, ErrDecryptionFailed
+	}
+	var nonce [24]byte
+	copy(nonce[:], sealed[:24])
+	plain, ok := secretbox.Open(nil, sealed[24:], &nonce, key)
+	if !ok {
+		return nil, ErrDecryptionFailed
+	}
+	return plain, nil
+}
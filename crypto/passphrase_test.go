@@ -0,0 +1,37 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+var testArgon2idParams = Argon2idParams{Time: 1, Memory: 1024, Threads: 1}
+
+func TestSecretboxWithPassphrase(t *testing.T) {
+	salt, err := NewSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DeriveArgon2idKey([]byte("correct horse battery staple"), salt, testArgon2idParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("private keys go here")
+	sealed, err := SecretboxSeal(key, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := SecretboxOpen(key, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, msg, plain)
+
+	wrongKey, err := DeriveArgon2idKey([]byte("wrong passphrase"), salt, testArgon2idParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = SecretboxOpen(wrongKey, sealed)
+	assert.Equal(t, ErrDecryptionFailed, err)
+}
@@ -0,0 +1,213 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// RFC 8410 algorithm identifiers
+var (
+	oidX25519  = asn1.ObjectIdentifier{1, 3, 101, 110}
+	oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+const (
+	pemTypePrivateKey = "PRIVATE KEY"
+	pemTypePublicKey  = "PUBLIC KEY"
+)
+
+type pkixAlgorithmIdentifier struct {
+	Algorithm asn1.ObjectIdentifier
+}
+
+// pkcs8 is OneAsymmetricKey (RFC 5958) without the optional attributes and public key
+type pkcs8 struct {
+	Version    int
+	Algorithm  pkixAlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// subjectPublicKeyInfo (RFC 5280)
+type subjectPublicKeyInfo struct {
+	Algorithm pkixAlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+func marshalPKCS8(oid asn1.ObjectIdentifier, rawKey []byte) ([]byte, error) {
+	// RFC 8410: CurvePrivateKey ::= OCTET STRING, wrapped in the PKCS#8 privateKey OCTET STRING
+	curvePrivateKey, err := asn1.Marshal(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs8{
+		Version:    0,
+		Algorithm:  pkixAlgorithmIdentifier{Algorithm: oid},
+		PrivateKey: curvePrivateKey,
+	})
+}
+
+func parsePKCS8(der []byte, oid asn1.ObjectIdentifier) ([]byte, error) {
+	var key pkcs8
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PKCS#8 private key: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("invalid PKCS#8 private key: trailing data")
+	}
+	if !key.Algorithm.Algorithm.Equal(oid) {
+		return nil, ErrBadKeyType
+	}
+	var rawKey []byte
+	rest, err = asn1.Unmarshal(key.PrivateKey, &rawKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PKCS#8 private key: %w", err)
+	}
+	if len(rest) > 0 || len(rawKey) != 32 {
+		return nil, errors.New("invalid PKCS#8 private key size")
+	}
+	return rawKey, nil
+}
+
+func marshalSPKI(oid asn1.ObjectIdentifier, rawKey []byte) ([]byte, error) {
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: pkixAlgorithmIdentifier{Algorithm: oid},
+		PublicKey: asn1.BitString{Bytes: rawKey, BitLength: 8 * len(rawKey)},
+	})
+}
+
+func parseSPKI(der []byte, oid asn1.ObjectIdentifier) ([]byte, error) {
+	var key subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SubjectPublicKeyInfo: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("invalid SubjectPublicKeyInfo: trailing data")
+	}
+	if !key.Algorithm.Algorithm.Equal(oid) {
+		return nil, ErrBadKeyType
+	}
+	if key.PublicKey.BitLength != 8*32 || len(key.PublicKey.Bytes) != 32 {
+		return nil, errors.New("invalid SubjectPublicKeyInfo public key size")
+	}
+	return key.PublicKey.Bytes, nil
+}
+
+func encodePEM(typ string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+}
+
+func decodePEM(data []byte, typ string) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if block.Type != typ {
+		return nil, fmt.Errorf("unexpected PEM type %q, expected %q", block.Type, typ)
+	}
+	return block.Bytes, nil
+}
+
+// MarshalEd25519PrivateKeyPEM encodes ed25519 private key as PEM PKCS#8 ("PRIVATE KEY")
+func MarshalEd25519PrivateKeyPEM(k PrivKey) ([]byte, error) {
+	edk, ok := k.(*Ed25519PrivateKey)
+	if !ok {
+		return nil, ErrBadKeyType
+	}
+	der, err := marshalPKCS8(oidEd25519, edk.k.Seed())
+	if err != nil {
+		return nil, err
+	}
+	return encodePEM(pemTypePrivateKey, der), nil
+}
+
+// UnmarshalEd25519PrivateKeyPEM decodes PEM PKCS#8 ed25519 private key (e.g. openssl genpkey -algorithm ed25519)
+func UnmarshalEd25519PrivateKeyPEM(data []byte) (PrivKey, error) {
+	der, err := decodePEM(data, pemTypePrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := parsePKCS8(der, oidEd25519)
+	if err != nil {
+		return nil, err
+	}
+	return &Ed25519PrivateKey{k: ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+// MarshalEd25519PublicKeyPEM encodes ed25519 public key as PEM SubjectPublicKeyInfo ("PUBLIC KEY")
+func MarshalEd25519PublicKeyPEM(k PubKey) ([]byte, error) {
+	edk, ok := k.(*Ed25519PublicKey)
+	if !ok {
+		return nil, ErrBadKeyType
+	}
+	der, err := marshalSPKI(oidEd25519, edk.k)
+	if err != nil {
+		return nil, err
+	}
+	return encodePEM(pemTypePublicKey, der), nil
+}
+
+// UnmarshalEd25519PublicKeyPEM decodes PEM SubjectPublicKeyInfo ed25519 public key
+func UnmarshalEd25519PublicKeyPEM(data []byte) (PubKey, error) {
+	der, err := decodePEM(data, pemTypePublicKey)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := parseSPKI(der, oidEd25519)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalEd25519PublicKey(raw)
+}
+
+// MarshalX25519PrivateKeyPEM encodes curve25519 encryption private key as PEM PKCS#8 ("PRIVATE KEY")
+func MarshalX25519PrivateKeyPEM(k PrivCKey) ([]byte, error) {
+	der, err := marshalPKCS8(oidX25519, k.Raw()[:])
+	if err != nil {
+		return nil, err
+	}
+	return encodePEM(pemTypePrivateKey, der), nil
+}
+
+// UnmarshalX25519PrivateKeyPEM decodes PEM PKCS#8 X25519 private key (e.g. openssl genpkey -algorithm x25519)
+func UnmarshalX25519PrivateKeyPEM(data []byte) (PrivCKey, error) {
+	der, err := decodePEM(data, pemTypePrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := parsePKCS8(der, oidX25519)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], raw)
+	return &Curve25519PrivateKey{Key: &key}, nil
+}
+
+// MarshalX25519PublicKeyPEM encodes curve25519 encryption public key as PEM SubjectPublicKeyInfo ("PUBLIC KEY")
+func MarshalX25519PublicKeyPEM(k PubCKey) ([]byte, error) {
+	der, err := marshalSPKI(oidX25519, k.Raw()[:])
+	if err != nil {
+		return nil, err
+	}
+	return encodePEM(pemTypePublicKey, der), nil
+}
+
+// UnmarshalX25519PublicKeyPEM decodes PEM SubjectPublicKeyInfo X25519 public key
+func UnmarshalX25519PublicKeyPEM(data []byte) (PubCKey, error) {
+	der, err := decodePEM(data, pemTypePublicKey)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := parseSPKI(der, oidX25519)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], raw)
+	return &Curve25519PublicKey{Key: &key}, nil
+}
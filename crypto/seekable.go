@@ -0,0 +1,177 @@
+package crypto
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Aes256ReaderAt decrypts random ranges of a stream written by NewAes256EncryptWriter. Only the chunks
+// covering the range are read and authenticated, modified chunks return ErrDecryptionFailed. Truncation is
+// detected when the decryptor is created (the last chunk must carry the final flag). Safe for concurrent ReadAt calls
+type Aes256ReaderAt struct {
+	r         io.ReaderAt
+	header    *streamHeader
+	aead      cipher.AEAD
+	ad        []byte
+	size      int64 // ciphertext size
+	chunks    int64
+	plainSize int64
+
+	mu          sync.Mutex
+	cachedIndex int64 // last decrypted chunk, speeds up sequential reads
+	cachedPlain []byte
+}
+
+// NewAes256DecryptReaderAt returns random access decryptor of encrypted stream r with ciphertext size
+func NewAes256DecryptReaderAt(r io.ReaderAt, size int64, key []byte) (*Aes256ReaderAt, error) {
+	return newStreamReaderAt(r, size, [][]byte{key}, nil)
+}
+
+// NewAes256DecryptReaderAtWithAD is NewAes256DecryptReaderAt for streams bound to associatedData
+func NewAes256DecryptReaderAtWithAD(r io.ReaderAt, size int64, key, associatedData []byte) (*Aes256ReaderAt, error) {
+	return newStreamReaderAt(r, size, [][]byte{key}, associatedData)
+}
+
+// NewAes256DecryptReaderAtWithKeys is NewAes256DecryptReaderAtWithAD trying each key (e.g. current and previous keys)
+func NewAes256DecryptReaderAtWithKeys(r io.ReaderAt, size int64, keys [][]byte, associatedData []byte) (*Aes256ReaderAt, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key required")
+	}
+	return newStreamReaderAt(r, size, keys, associatedData)
+}
+
+// NewAes256DecryptReadSeeker returns io.ReadSeeker (and io.ReaderAt) over the decrypted stream, e.g. for http.ServeContent
+func NewAes256DecryptReadSeeker(r io.ReaderAt, size int64, key []byte) (*io.SectionReader, error) {
+	ra, err := NewAes256DecryptReaderAt(r, size, key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(ra, 0, ra.Size()), nil
+}
+
+// newStreamReaderAt reads the header and opens the first chunk to pick the key
+func newStreamReaderAt(r io.ReaderAt, size int64, keys [][]byte, associatedData []byte) (*Aes256ReaderAt, error) {
+	raw := make([]byte, streamHeaderSize)
+	if size < streamHeaderSize {
+		return nil, ErrInvalidStreamHeader
+	}
+	if n, err := r.ReadAt(raw, 0); n != len(raw) {
+		if err == nil || err == io.EOF {
+			err = ErrInvalidStreamHeader
+		}
+		return nil, err
+	}
+	header, err := parseStreamHeader(raw)
+	if err != nil {
+		return nil, err
+	}
+	chunkSize := int64(header.chunkSize) + streamTagSize
+	body := size - streamHeaderSize
+	chunks := (body + chunkSize - 1) / chunkSize
+	if chunks == 0 || body-(chunks-1)*chunkSize < streamTagSize {
+		return nil, ErrDecryptionFailed
+	}
+	if chunks > 1<<32 {
+		return nil, ErrStreamTooLong
+	}
+	s := &Aes256ReaderAt{
+		r:           r,
+		header:      header,
+		ad:          header.additionalData(associatedData),
+		size:        size,
+		chunks:      chunks,
+		plainSize:   body - chunks*streamTagSize,
+		cachedIndex: -1,
+	}
+	for _, key := range keys {
+		aead, err := header.aead(key)
+		if err != nil {
+			return nil, err
+		}
+		s.aead = aead
+		plain, err := s.openChunk(0)
+		if err == ErrDecryptionFailed {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// last chunk carries the final flag, opening it detects truncation before any range is read
+		if s.chunks > 1 {
+			if _, err := s.openChunk(s.chunks - 1); err != nil {
+				return nil, err
+			}
+		}
+		s.cachedIndex = 0
+		s.cachedPlain = plain
+		return s, nil
+	}
+	return nil, ErrDecryptionFailed
+}
+
+// Size returns the plaintext size
+func (s *Aes256ReaderAt) Size() int64 {
+	return s.plainSize
+}
+
+func (s *Aes256ReaderAt) openChunk(index int64) ([]byte, error) {
+	chunkSize := int64(s.header.chunkSize) + streamTagSize
+	offset := streamHeaderSize + index*chunkSize
+	length := chunkSize
+	if offset+length > s.size {
+		length = s.size - offset
+	}
+	in := make([]byte, length)
+	if n, err := s.r.ReadAt(in, offset); n != len(in) {
+		if err == nil || err == io.EOF {
+			err = ErrDecryptionFailed
+		}
+		return nil, err
+	}
+	final := index == s.chunks-1
+	plain, err := s.aead.Open(in[:0], s.header.nonce(uint32(index), final), in, s.ad)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plain, nil
+}
+
+func (s *Aes256ReaderAt) chunk(index int64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index == s.cachedIndex {
+		return s.cachedPlain, nil
+	}
+	plain, err := s.openChunk(index)
+	if err != nil {
+		return nil, err
+	}
+	s.cachedIndex = index
+	s.cachedPlain = plain
+	return plain, nil
+}
+
+// ReadAt decrypts len(p) plaintext bytes starting at plaintext offset off
+func (s *Aes256ReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	n := 0
+	chunkSize := int64(s.header.chunkSize)
+	for n < len(p) && off < s.plainSize {
+		index := off / chunkSize
+		plain, err := s.chunk(index)
+		if err != nil {
+			return n, err
+		}
+		c := copy(p[n:], plain[off-index*chunkSize:])
+		n += c
+		off += int64(c)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,87 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestAes256ReaderAt(t *testing.T) {
+	key, _ := New32ByteKey()
+	plain := make([]byte, 100)
+	rand.Read(plain)
+	encrypted := encryptStream(t, key, plain, 16)
+
+	ra, err := NewAes256DecryptReaderAt(bytes.NewReader(encrypted), int64(len(encrypted)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(len(plain)), ra.Size())
+
+	for _, r := range [][2]int{{0, 100}, {0, 1}, {15, 17}, {16, 32}, {40, 90}, {99, 100}, {5, 5}} {
+		buf := make([]byte, r[1]-r[0])
+		n, err := ra.ReadAt(buf, int64(r[0]))
+		assert.NoError(t, err)
+		assert.Equal(t, len(buf), n)
+		assert.Equal(t, plain[r[0]:r[1]], buf)
+	}
+	buf := make([]byte, 10)
+	n, err := ra.ReadAt(buf, 95)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, plain[95:], buf[:n])
+	_, err = ra.ReadAt(buf, 100)
+	assert.Equal(t, io.EOF, err)
+
+	rs, err := NewAes256DecryptReadSeeker(bytes.NewReader(encrypted), int64(len(encrypted)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rs.Seek(50, io.SeekStart)
+	assert.NoError(t, err)
+	rest, err := ioutil.ReadAll(rs)
+	assert.NoError(t, err)
+	assert.Equal(t, plain[50:], rest)
+
+	// empty stream
+	empty := encryptStream(t, key, nil, 16)
+	ra, err = NewAes256DecryptReaderAt(bytes.NewReader(empty), int64(len(empty)), key)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), ra.Size())
+}
+
+func TestAes256ReaderAtTampering(t *testing.T) {
+	key, _ := New32ByteKey()
+	plain := make([]byte, 64)
+	rand.Read(plain)
+	encrypted := encryptStream(t, key, plain, 16)
+	chunk := 16 + streamTagSize
+
+	// modified third chunk fails only for ranges covering it
+	modified := append([]byte(nil), encrypted...)
+	modified[streamHeaderSize+2*chunk+3] ^= 1
+	ra, err := NewAes256DecryptReaderAt(bytes.NewReader(modified), int64(len(modified)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	_, err = ra.ReadAt(buf, 0)
+	assert.NoError(t, err)
+	_, err = ra.ReadAt(buf, 40)
+	assert.Equal(t, ErrDecryptionFailed, err)
+
+	// truncated at chunk boundary
+	truncated := encrypted[:len(encrypted)-chunk]
+	_, err = NewAes256DecryptReaderAt(bytes.NewReader(truncated), int64(len(truncated)), key)
+	assert.Equal(t, ErrDecryptionFailed, err)
+
+	otherKey, _ := New32ByteKey()
+	_, err = NewAes256DecryptReaderAt(bytes.NewReader(encrypted), int64(len(encrypted)), otherKey)
+	assert.Equal(t, ErrDecryptionFailed, err)
+	ra, err = NewAes256DecryptReaderAtWithKeys(bytes.NewReader(encrypted), int64(len(encrypted)), [][]byte{otherKey, key}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(64), ra.Size())
+}
@@ -0,0 +1,207 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrInvalidShare is returned for malformed or corrupted shares
+	ErrInvalidShare = errors.New("invalid or corrupted share")
+	// ErrNotEnoughShares is returned when fewer shares than the threshold are combined
+	ErrNotEnoughShares = errors.New("not enough shares")
+	// ErrSharesMismatch is returned when shares come from different splits or reconstruct a wrong secret
+	ErrSharesMismatch = errors.New("shares don't belong together")
+)
+
+const (
+	shareVersion      = 1
+	shareSetIDSize    = 8
+	shareChecksumSize = 4
+	// version, set ID, threshold, x
+	shareHeaderSize = 1 + shareSetIDSize + 1 + 1
+	// secret is combined with a digest to detect wrong reconstruction
+	secretDigestSize = 8
+)
+
+// GF(2^8) with the AES polynomial x^8 + x^4 + x^3 + x + 1
+var gfExp, gfLog = func() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var log [256]byte
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		log[x] = byte(i)
+		// multiply by generator 3
+		hi := x & 0x80
+		x2 := x << 1
+		if hi != 0 {
+			x2 ^= 0x1b
+		}
+		x ^= x2
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// SplitSecret splits secret into n shares, any threshold of which reconstruct it (Shamir secret sharing
+// over GF(256)). Each share carries a split ID, the threshold and a checksum so corrupted shares and shares
+// from different splits are detected by CombineShares
+func SplitSecret(secret []byte, threshold, n int) ([][]byte, error) {
+	if threshold < 2 || n < threshold || n > 255 {
+		return nil, errors.New("threshold must be at least 2 and shares between threshold and 255")
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("secret must not be empty")
+	}
+	digest := sha256.Sum256(secret)
+	payload := append(append([]byte(nil), secret...), digest[:secretDigestSize]...)
+
+	setID := make([]byte, shareSetIDSize)
+	if _, err := io.ReadFull(rand.Reader, setID); err != nil {
+		return nil, err
+	}
+	shares := make([][]byte, n)
+	for i := range shares {
+		share := make([]byte, 0, shareHeaderSize+len(payload)+shareChecksumSize)
+		share = append(share, shareVersion)
+		share = append(share, setID...)
+		share = append(share, byte(threshold), byte(i+1))
+		shares[i] = share
+	}
+
+	coefficients := make([]byte, threshold-1)
+	for _, b := range payload {
+		if _, err := io.ReadFull(rand.Reader, coefficients); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			// Horner's rule for b + c1*x + c2*x^2 ...
+			x := byte(i + 1)
+			y := byte(0)
+			for j := len(coefficients) - 1; j >= 0; j-- {
+				y = gfMul(y^coefficients[j], x)
+			}
+			shares[i] = append(shares[i], y^b)
+		}
+	}
+	for i := range shares {
+		checksum := sha256.Sum256(shares[i])
+		shares[i] = append(shares[i], checksum[:shareChecksumSize]...)
+	}
+	return shares, nil
+}
+
+// CombineShares reconstructs the secret from at least threshold shares created by SplitSecret
+func CombineShares(shares [][]byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, ErrNotEnoughShares
+	}
+	var setID []byte
+	var threshold, payloadLen int
+	xs := make([]byte, 0, len(shares))
+	seen := make(map[byte]bool)
+	for _, share := range shares {
+		if len(share) < shareHeaderSize+secretDigestSize+1+shareChecksumSize || share[0] != shareVersion {
+			return nil, ErrInvalidShare
+		}
+		body := share[:len(share)-shareChecksumSize]
+		checksum := sha256.Sum256(body)
+		if subtle.ConstantTimeCompare(checksum[:shareChecksumSize], share[len(body):]) != 1 {
+			return nil, ErrInvalidShare
+		}
+		x := share[1+shareSetIDSize+1]
+		if x == 0 {
+			return nil, ErrInvalidShare
+		}
+		if setID == nil {
+			setID = share[1 : 1+shareSetIDSize]
+			threshold = int(share[1+shareSetIDSize])
+			payloadLen = len(body) - shareHeaderSize
+		} else if string(setID) != string(share[1:1+shareSetIDSize]) || threshold != int(share[1+shareSetIDSize]) || payloadLen != len(body)-shareHeaderSize {
+			return nil, ErrSharesMismatch
+		}
+		// duplicates don't count towards the threshold
+		if seen[x] {
+			continue
+		}
+		seen[x] = true
+		xs = append(xs, x)
+	}
+	if len(xs) < threshold {
+		return nil, ErrNotEnoughShares
+	}
+
+	// Lagrange interpolation at x = 0 over the first threshold distinct shares
+	xs = xs[:threshold]
+	ys := make([][]byte, 0, threshold)
+	for _, x := range xs {
+		for _, share := range shares {
+			if share[1+shareSetIDSize+1] == x {
+				ys = append(ys, share[shareHeaderSize:len(share)-shareChecksumSize])
+				break
+			}
+		}
+	}
+	payload := make([]byte, payloadLen)
+	for i, xi := range xs {
+		basis := byte(1)
+		for j, xj := range xs {
+			if i != j {
+				basis = gfMul(basis, gfDiv(xj, xj^xi))
+			}
+		}
+		for k := range payload {
+			payload[k] ^= gfMul(ys[i][k], basis)
+		}
+	}
+
+	secret := payload[:payloadLen-secretDigestSize]
+	digest := sha256.Sum256(secret)
+	if subtle.ConstantTimeCompare(digest[:secretDigestSize], payload[payloadLen-secretDigestSize:]) != 1 {
+		return nil, ErrSharesMismatch
+	}
+	return secret, nil
+}
+
+// EncodeShare encodes share as base64 text (for printing, QR codes, secret stores)
+func EncodeShare(share []byte) string {
+	return base64.StdEncoding.EncodeToString(share)
+}
+
+// DecodeShare decodes base64 share created by EncodeShare
+func DecodeShare(encoded string) ([]byte, error) {
+	share, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidShare
+	}
+	return share, nil
+}
+
+// ShareThreshold returns the number of shares required to reconstruct the secret
+func ShareThreshold(share []byte) (int, error) {
+	if len(share) < shareHeaderSize || share[0] != shareVersion {
+		return 0, ErrInvalidShare
+	}
+	return int(share[1+shareSetIDSize]), nil
+}
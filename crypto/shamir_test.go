@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestSplitCombineSecret(t *testing.T) {
+	secret := []byte("domain private keys")
+	shares, err := SplitSecret(secret, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 5, len(shares))
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var selected [][]byte
+		for _, i := range subset {
+			selected = append(selected, shares[i])
+		}
+		combined, err := CombineShares(selected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, secret, combined)
+	}
+
+	_, err = CombineShares(shares[:2])
+	assert.Equal(t, ErrNotEnoughShares, err)
+	_, err = CombineShares([][]byte{shares[0], shares[0], shares[1]})
+	assert.Equal(t, ErrNotEnoughShares, err)
+
+	threshold, err := ShareThreshold(shares[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, threshold)
+}
+
+func TestCorruptedShare(t *testing.T) {
+	secret := []byte("domain private keys")
+	shares, err := SplitSecret(secret, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted := append([]byte(nil), shares[1]...)
+	corrupted[len(corrupted)/2] ^= 0x01
+	_, err = CombineShares([][]byte{shares[0], corrupted})
+	assert.Equal(t, ErrInvalidShare, err)
+
+	otherShares, err := SplitSecret(secret, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = CombineShares([][]byte{shares[0], otherShares[1]})
+	assert.Equal(t, ErrSharesMismatch, err)
+
+	decoded, err := DecodeShare(EncodeShare(shares[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	combined, err := CombineShares([][]byte{decoded, shares[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, secret, combined)
+}
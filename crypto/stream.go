@@ -0,0 +1,333 @@
+package crypto
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Stream format: header (version, chunk size, salt, nonce prefix) followed by AES-256-GCM sealed chunks.
+// Every chunk but the last has chunk size plaintext bytes. Chunk nonce is nonce prefix || chunk counter || final flag
+// so chunks can't be reordered, dropped or the stream truncated. Chunk key is derived from the key and the random salt
+const (
+	// StreamChunkSize is the default plaintext chunk size
+	StreamChunkSize = 64 * 1024
+	// MaxStreamChunkSize is the largest supported chunk size
+	MaxStreamChunkSize = 16 * 1024 * 1024
+
+	streamVersion         = 1
+	streamSaltSize        = 16
+	streamNoncePrefixSize = 7
+	streamHeaderSize      = 1 + 4 + streamSaltSize + streamNoncePrefixSize
+	streamTagSize         = 16
+	streamKeyInfo         = "mcrypt stream"
+)
+
+var (
+	// ErrInvalidStreamHeader is returned when stream doesn't start with a valid header
+	ErrInvalidStreamHeader = errors.New("invalid encrypted stream header")
+	// ErrStreamTooLong is returned when stream exceeds 2^32 chunks
+	ErrStreamTooLong = errors.New("encrypted stream too long")
+	// ErrStreamClosed is returned when writing to a closed stream
+	ErrStreamClosed = errors.New("encrypted stream is closed")
+)
+
+type streamHeader struct {
+	raw         []byte
+	chunkSize   int
+	salt        []byte
+	noncePrefix []byte
+}
+
+func newStreamHeader(chunkSize int) (*streamHeader, error) {
+	if chunkSize < 1 || chunkSize > MaxStreamChunkSize {
+		return nil, errors.New("invalid stream chunk size")
+	}
+	raw := make([]byte, streamHeaderSize)
+	raw[0] = streamVersion
+	binary.BigEndian.PutUint32(raw[1:5], uint32(chunkSize))
+	if _, err := io.ReadFull(rand.Reader, raw[5:]); err != nil {
+		return nil, err
+	}
+	return parseStreamHeader(raw)
+}
+
+func parseStreamHeader(raw []byte) (*streamHeader, error) {
+	if len(raw) != streamHeaderSize || raw[0] != streamVersion {
+		return nil, ErrInvalidStreamHeader
+	}
+	chunkSize := binary.BigEndian.Uint32(raw[1:5])
+	if chunkSize < 1 || chunkSize > MaxStreamChunkSize {
+		return nil, ErrInvalidStreamHeader
+	}
+	return &streamHeader{
+		raw:         raw,
+		chunkSize:   int(chunkSize),
+		salt:        raw[5 : 5+streamSaltSize],
+		noncePrefix: raw[5+streamSaltSize:],
+	}, nil
+}
+
+// aead derives the stream chunk key from key and header salt
+func (h *streamHeader) aead(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("Key must be 32 bytes long")
+	}
+	chunkKey := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, h.salt, []byte(streamKeyInfo)), chunkKey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(chunkKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (h *streamHeader) nonce(counter uint32, final bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, h.noncePrefix)
+	binary.BigEndian.PutUint32(nonce[streamNoncePrefixSize:], counter)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// additionalData of every chunk authenticates the header and associated data of the stream
+func (h *streamHeader) additionalData(associatedData []byte) []byte {
+	return append(append([]byte(nil), h.raw...), associatedData...)
+}
+
+type streamWriter struct {
+	w       io.Writer
+	header  *streamHeader
+	aead    cipher.AEAD
+	ad      []byte
+	buf     []byte
+	out     []byte
+	counter uint32
+	closed  bool
+	err     error
+}
+
+// NewAes256EncryptWriter returns writer encrypting everything written to it into w (chunked AES-256-GCM).
+// Key must be 32 bytes long (e.g. New32ByteKey or Curve25519SharedKey). Close must be called to write
+// the final chunk, it doesn't close w
+func NewAes256EncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	return newStreamWriter(w, key, StreamChunkSize, nil)
+}
+
+// NewAes256EncryptWriterWithAD is NewAes256EncryptWriter binding the stream to associatedData (e.g. object ID)
+func NewAes256EncryptWriterWithAD(w io.Writer, key, associatedData []byte) (io.WriteCloser, error) {
+	return newStreamWriter(w, key, StreamChunkSize, associatedData)
+}
+
+// NewAes256EncryptWriterWithChunkSize is NewAes256EncryptWriter with custom plaintext chunk size
+func NewAes256EncryptWriterWithChunkSize(w io.Writer, key []byte, chunkSize int) (io.WriteCloser, error) {
+	return newStreamWriter(w, key, chunkSize, nil)
+}
+
+func newStreamWriter(w io.Writer, key []byte, chunkSize int, associatedData []byte) (*streamWriter, error) {
+	header, err := newStreamHeader(chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := header.aead(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header.raw); err != nil {
+		return nil, err
+	}
+	return &streamWriter{
+		w:      w,
+		header: header,
+		aead:   aead,
+		ad:     header.additionalData(associatedData),
+		buf:    make([]byte, 0, chunkSize),
+		out:    make([]byte, 0, chunkSize+streamTagSize),
+	}, nil
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, ErrStreamClosed
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	written := 0
+	for len(p) > 0 {
+		// a full chunk is sealed only when more data follows, the last chunk is sealed on Close
+		if len(s.buf) == cap(s.buf) {
+			if err := s.sealChunk(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(s.buf[len(s.buf):cap(s.buf)], p)
+		s.buf = s.buf[:len(s.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (s *streamWriter) sealChunk(final bool) error {
+	if s.counter == ^uint32(0) && !final {
+		s.err = ErrStreamTooLong
+		return s.err
+	}
+	s.out = s.aead.Seal(s.out[:0], s.header.nonce(s.counter, final), s.buf, s.ad)
+	if _, err := s.w.Write(s.out); err != nil {
+		s.err = err
+		return err
+	}
+	s.counter++
+	s.buf = s.buf[:0]
+	return nil
+}
+
+// Close writes the final chunk
+func (s *streamWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	if s.err != nil {
+		return s.err
+	}
+	s.closed = true
+	return s.sealChunk(true)
+}
+
+type streamReader struct {
+	r       *bufio.Reader
+	header  *streamHeader
+	aead    cipher.AEAD
+	keys    [][]byte // candidate keys until the first chunk opens
+	ad      []byte
+	in      []byte
+	plain   []byte
+	pending []byte
+	counter uint32
+	final   bool
+	err     error
+}
+
+// NewAes256DecryptReader returns reader decrypting stream written by NewAes256EncryptWriter.
+// Read returns ErrDecryptionFailed for modified, reordered or truncated streams
+func NewAes256DecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	return newStreamReader(r, [][]byte{key}, nil)
+}
+
+// NewAes256DecryptReaderWithAD decrypts stream written by NewAes256EncryptWriterWithAD with the same associatedData
+func NewAes256DecryptReaderWithAD(r io.Reader, key, associatedData []byte) (io.Reader, error) {
+	return newStreamReader(r, [][]byte{key}, associatedData)
+}
+
+// NewAes256DecryptReaderWithKeys is NewAes256DecryptReaderWithAD trying each key (e.g. current and previous keys)
+func NewAes256DecryptReaderWithKeys(r io.Reader, keys [][]byte, associatedData []byte) (io.Reader, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key required")
+	}
+	return newStreamReader(r, keys, associatedData)
+}
+
+// newStreamReader reads the header. The key opening the first chunk is used for the whole stream
+func newStreamReader(r io.Reader, keys [][]byte, associatedData []byte) (*streamReader, error) {
+	raw := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, ErrInvalidStreamHeader
+	}
+	header, err := parseStreamHeader(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if len(key) != 32 {
+			return nil, errors.New("Key must be 32 bytes long")
+		}
+	}
+	return &streamReader{
+		r:      bufio.NewReader(r),
+		header: header,
+		keys:   keys,
+		ad:     header.additionalData(associatedData),
+		in:     make([]byte, header.chunkSize+streamTagSize),
+	}, nil
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.final {
+			return 0, io.EOF
+		}
+		s.err = s.openChunk()
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *streamReader) openChunk() error {
+	n, err := io.ReadFull(s.r, s.in)
+	final := false
+	switch err {
+	case nil:
+		// a full chunk is final only when nothing follows
+		if _, err := s.r.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	case io.EOF, io.ErrUnexpectedEOF:
+		final = true
+	default:
+		return err
+	}
+	if n < streamTagSize {
+		return ErrDecryptionFailed
+	}
+	nonce := s.header.nonce(s.counter, final)
+	if s.aead == nil {
+		for _, key := range s.keys {
+			aead, err := s.header.aead(key)
+			if err != nil {
+				return err
+			}
+			plain, err := aead.Open(s.plain[:0], nonce, s.in[:n], s.ad)
+			if err == nil {
+				s.aead = aead
+				s.keys = nil
+				s.plain = plain
+				break
+			}
+		}
+		if s.aead == nil {
+			return ErrDecryptionFailed
+		}
+	} else {
+		plain, err := s.aead.Open(s.plain[:0], nonce, s.in[:n], s.ad)
+		if err != nil {
+			return ErrDecryptionFailed
+		}
+		s.plain = plain
+	}
+	if s.counter == ^uint32(0) && !final {
+		return ErrStreamTooLong
+	}
+	s.counter++
+	s.final = final
+	s.pending = s.plain
+	return nil
+}
@@ -0,0 +1,175 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func encryptStream(t *testing.T, key, plain []byte, chunkSize int) []byte {
+	var buf bytes.Buffer
+	w, err := NewAes256EncryptWriterWithChunkSize(&buf, key, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// odd sized writes
+	for len(plain) > 0 {
+		n := 7
+		if n > len(plain) {
+			n = len(plain)
+		}
+		if _, err := w.Write(plain[:n]); err != nil {
+			t.Fatal(err)
+		}
+		plain = plain[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(key, encrypted []byte) ([]byte, error) {
+	r, err := NewAes256DecryptReader(bytes.NewReader(encrypted), key)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func TestAes256Stream(t *testing.T) {
+	key, err := New32ByteKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 1, 15, 16, 17, 48, 100} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+		encrypted := encryptStream(t, key, plain, 16)
+		chunks := size/16 + 1
+		if size > 0 && size%16 == 0 {
+			chunks = size / 16
+		}
+		assert.Equal(t, streamHeaderSize+size+chunks*streamTagSize, len(encrypted))
+
+		decrypted, err := decryptStream(key, encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, plain, decrypted)
+	}
+
+	// large payload with default chunk size
+	plain := make([]byte, 3*StreamChunkSize+123)
+	rand.Read(plain)
+	var buf bytes.Buffer
+	w, err := NewAes256EncryptWriter(&buf, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.Copy(w, bytes.NewReader(plain))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	decrypted, err := decryptStream(key, buf.Bytes())
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(plain, decrypted))
+}
+
+func TestAes256StreamTampering(t *testing.T) {
+	key, _ := New32ByteKey()
+	plain := make([]byte, 64)
+	rand.Read(plain)
+	encrypted := encryptStream(t, key, plain, 16)
+	chunk := 16 + streamTagSize
+
+	// truncated at chunk boundary (final chunk dropped)
+	_, err := decryptStream(key, encrypted[:len(encrypted)-chunk])
+	assert.Equal(t, ErrDecryptionFailed, err)
+
+	// truncated inside a chunk
+	_, err = decryptStream(key, encrypted[:len(encrypted)-5])
+	assert.Equal(t, ErrDecryptionFailed, err)
+
+	// reordered chunks
+	reordered := append([]byte(nil), encrypted...)
+	first := streamHeaderSize
+	copy(reordered[first:], encrypted[first+chunk:first+2*chunk])
+	copy(reordered[first+chunk:], encrypted[first:first+chunk])
+	_, err = decryptStream(key, reordered)
+	assert.Equal(t, ErrDecryptionFailed, err)
+
+	// modified ciphertext and header
+	for _, i := range []int{3, streamHeaderSize + 1, len(encrypted) - 1} {
+		modified := append([]byte(nil), encrypted...)
+		modified[i] ^= 1
+		_, err = decryptStream(key, modified)
+		assert.Error(t, err)
+	}
+
+	otherKey, _ := New32ByteKey()
+	_, err = decryptStream(otherKey, encrypted)
+	assert.Equal(t, ErrDecryptionFailed, err)
+
+	_, err = decryptStream(key, encrypted[:10])
+	assert.Equal(t, ErrInvalidStreamHeader, err)
+}
+
+func TestCurve25519SharedKeyStream(t *testing.T) {
+	alicePriv, alicePub, _ := GenerateCryptKeys(rand.Reader)
+	bobPriv, bobPub, _ := GenerateCryptKeys(rand.Reader)
+
+	aliceKey, err := Curve25519SharedKey(alicePriv, bobPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobKey, err := Curve25519SharedKey(bobPriv, alicePub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, aliceKey, bobKey)
+
+	plain := []byte("mail attachment streamed from alice to bob")
+	encrypted := encryptStream(t, aliceKey, plain, 16)
+	decrypted, err := decryptStream(bobKey, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, plain, decrypted)
+}
+
+func TestAes256StreamWithAD(t *testing.T) {
+	key, _ := New32ByteKey()
+	plain := []byte("attachment bound to its message")
+	var buf bytes.Buffer
+	w, err := NewAes256EncryptWriterWithAD(&buf, key, []byte("message-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Write(plain)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	encrypted := buf.Bytes()
+
+	r, err := NewAes256DecryptReaderWithAD(bytes.NewReader(encrypted), key, []byte("message-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, plain, decrypted)
+
+	r, err = NewAes256DecryptReaderWithAD(bytes.NewReader(encrypted), key, []byte("message-2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(r)
+	assert.Equal(t, ErrDecryptionFailed, err)
+
+	ra, err := NewAes256DecryptReaderAtWithAD(bytes.NewReader(encrypted), int64(len(encrypted)), key, []byte("message-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(plain)), ra.Size())
+	_, err = NewAes256DecryptReaderAt(bytes.NewReader(encrypted), int64(len(encrypted)), key)
+	assert.Equal(t, ErrDecryptionFailed, err)
+}
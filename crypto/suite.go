@@ -0,0 +1,241 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// SuiteID identifies the cipher suite of a versioned ciphertext
+type SuiteID uint8
+
+// Built-in cipher suites. All of them take 32 byte keys
+const (
+	// SuiteAes256GCM is AES-256-GCM with random 96-bit nonces (Aes256EncryptWithAD)
+	SuiteAes256GCM SuiteID = 1
+	// SuiteXChaCha20Poly1305 is XChaCha20-Poly1305 with random 192-bit nonces (XChaCha20Poly1305EncryptWithAD)
+	SuiteXChaCha20Poly1305 SuiteID = 2
+	// SuiteCurve25519XSalsa20Poly1305 is NaCl box with associated data, the key is the box shared key (PrecomputeSharedKey)
+	SuiteCurve25519XSalsa20Poly1305 SuiteID = 3
+)
+
+// CiphertextVersion is the current versioned ciphertext header version
+const CiphertextVersion = 1
+
+// versioned ciphertext: magic "MCR" | version | suite ID | key ID length | key ID | suite ciphertext.
+// The header is authenticated as a prefix of the associated data
+var ciphertextMagic = []byte("MCR")
+
+const ciphertextFixedHeaderSize = 3 + 1 + 1 + 1
+
+var (
+	// ErrInvalidCiphertextHeader is returned for ciphertexts without a valid versioned header
+	ErrInvalidCiphertextHeader = errors.New("invalid ciphertext header")
+	// ErrUnsupportedCiphertextVersion is returned for ciphertexts created by a newer version of this library
+	ErrUnsupportedCiphertextVersion = errors.New("unsupported ciphertext version")
+	// ErrUnknownCipherSuite is returned for suite IDs that are not registered
+	ErrUnknownCipherSuite = errors.New("unknown cipher suite")
+)
+
+// CipherSuite is an AEAD algorithm that can be registered with RegisterCipherSuite
+type CipherSuite interface {
+	ID() SuiteID
+	Name() string
+	Encrypt(key, plaintext, associatedData []byte) ([]byte, error)
+	Decrypt(key, ciphertext, associatedData []byte) ([]byte, error)
+}
+
+var (
+	cipherSuitesMu sync.RWMutex
+	cipherSuites   = map[SuiteID]CipherSuite{}
+)
+
+func init() {
+	for _, s := range []CipherSuite{
+		&aeadSuite{SuiteAes256GCM, "aes-256-gcm", Aes256EncryptWithAD, Aes256DecryptWithAD},
+		&aeadSuite{SuiteXChaCha20Poly1305, "xchacha20-poly1305", XChaCha20Poly1305EncryptWithAD, XChaCha20Poly1305DecryptWithAD},
+		&aeadSuite{SuiteCurve25519XSalsa20Poly1305, "curve25519-xsalsa20-poly1305", boxSealWithAD, boxOpenWithAD},
+	} {
+		if err := RegisterCipherSuite(s); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterCipherSuite adds a cipher suite. Suite IDs can't be reused, ciphertexts store only the ID
+func RegisterCipherSuite(suite CipherSuite) error {
+	cipherSuitesMu.Lock()
+	defer cipherSuitesMu.Unlock()
+	if suite.ID() == 0 {
+		return errors.New("cipher suite ID 0 is reserved")
+	}
+	if _, ok := cipherSuites[suite.ID()]; ok {
+		return fmt.Errorf("cipher suite %d already registered", suite.ID())
+	}
+	cipherSuites[suite.ID()] = suite
+	return nil
+}
+
+// CipherSuiteByID returns registered cipher suite
+func CipherSuiteByID(id SuiteID) (CipherSuite, error) {
+	cipherSuitesMu.RLock()
+	defer cipherSuitesMu.RUnlock()
+	suite, ok := cipherSuites[id]
+	if !ok {
+		return nil, ErrUnknownCipherSuite
+	}
+	return suite, nil
+}
+
+type aeadSuite struct {
+	id      SuiteID
+	name    string
+	encrypt func(key, plaintext, associatedData []byte) ([]byte, error)
+	decrypt func(key, ciphertext, associatedData []byte) ([]byte, error)
+}
+
+func (s *aeadSuite) ID() SuiteID {
+	return s.id
+}
+
+func (s *aeadSuite) Name() string {
+	return s.name
+}
+
+func (s *aeadSuite) Encrypt(key, plaintext, associatedData []byte) ([]byte, error) {
+	return s.encrypt(key, plaintext, associatedData)
+}
+
+func (s *aeadSuite) Decrypt(key, ciphertext, associatedData []byte) ([]byte, error) {
+	return s.decrypt(key, ciphertext, associatedData)
+}
+
+// boxAssociatedDataKey is the secretbox key binding associatedData: HMAC-SHA256 keyed by the box shared key
+func boxAssociatedDataKey(sharedKey, associatedData []byte) *[32]byte {
+	mac := hmac.New(sha256.New, sharedKey)
+	mac.Write(associatedData)
+	var key [32]byte
+	copy(key[:], mac.Sum(nil))
+	return &key
+}
+
+func boxSealWithAD(sharedKey, plaintext, associatedData []byte) ([]byte, error) {
+	if len(sharedKey) != 32 {
+		return nil, errors.New("Key must be 32 bytes long")
+	}
+	nonce, err := Nonce()
+	if err != nil {
+		return nil, err
+	}
+	return secretbox.Seal(nonce[:], plaintext, &nonce, boxAssociatedDataKey(sharedKey, associatedData)), nil
+}
+
+func boxOpenWithAD(sharedKey, ciphertext, associatedData []byte) ([]byte, error) {
+	if len(sharedKey) != 32 {
+		return nil, errors.New("Key must be 32 bytes long")
+	}
+	if len(ciphertext) < 24 {
+		return nil, ErrDecryptionFailed
+	}
+	var nonce [24]byte
+	copy(nonce[:], ciphertext[:24])
+	plain, ok := secretbox.Open(nil, ciphertext[24:], &nonce, boxAssociatedDataKey(sharedKey, associatedData))
+	if !ok {
+		return nil, ErrDecryptionFailed
+	}
+	return plain, nil
+}
+
+// CiphertextHeader describes a versioned ciphertext
+type CiphertextHeader struct {
+	Version uint8
+	Suite   SuiteID
+	KeyID   string
+	// Size of the header in bytes
+	Size int
+}
+
+func marshalCiphertextHeader(suite SuiteID, keyID string) ([]byte, error) {
+	if len(keyID) > 255 {
+		return nil, errors.New("key ID too long")
+	}
+	header := make([]byte, 0, ciphertextFixedHeaderSize+len(keyID))
+	header = append(header, ciphertextMagic...)
+	header = append(header, CiphertextVersion, byte(suite), byte(len(keyID)))
+	return append(header, keyID...), nil
+}
+
+// ParseCiphertextHeader reads the header of a versioned ciphertext
+func ParseCiphertextHeader(ciphertext []byte) (*CiphertextHeader, error) {
+	if len(ciphertext) < ciphertextFixedHeaderSize || !hmac.Equal(ciphertext[:3], ciphertextMagic) {
+		return nil, ErrInvalidCiphertextHeader
+	}
+	if ciphertext[3] != CiphertextVersion {
+		return nil, ErrUnsupportedCiphertextVersion
+	}
+	size := ciphertextFixedHeaderSize + int(ciphertext[5])
+	if len(ciphertext) < size {
+		return nil, ErrInvalidCiphertextHeader
+	}
+	return &CiphertextHeader{
+		Version: ciphertext[3],
+		Suite:   SuiteID(ciphertext[4]),
+		KeyID:   string(ciphertext[ciphertextFixedHeaderSize:size]),
+		Size:    size,
+	}, nil
+}
+
+// IsVersionedCiphertext reports whether ciphertext starts with a versioned header (as opposed to bare
+// ciphertexts of Aes256Encrypt or Curve25519PrivateKey.Encrypt)
+func IsVersionedCiphertext(ciphertext []byte) bool {
+	_, err := ParseCiphertextHeader(ciphertext)
+	return err == nil
+}
+
+// KeyResolver returns the key for suite and key ID of a versioned ciphertext
+type KeyResolver func(suite SuiteID, keyID string) ([]byte, error)
+
+// Encrypt encrypts plaintext with the cipher suite and prepends the versioned header with suite and key ID.
+// associatedData is authenticated together with the header and must be provided again for decryption
+func Encrypt(suiteID SuiteID, key []byte, keyID string, plaintext, associatedData []byte) ([]byte, error) {
+	suite, err := CipherSuiteByID(suiteID)
+	if err != nil {
+		return nil, err
+	}
+	header, err := marshalCiphertextHeader(suiteID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := suite.Encrypt(key, plaintext, append(append([]byte(nil), header...), associatedData...))
+	if err != nil {
+		return nil, err
+	}
+	return append(header, sealed...), nil
+}
+
+// Decrypt decrypts versioned ciphertext created by Encrypt with the suite from its header and the key
+// returned by keys for the header key ID
+func Decrypt(ciphertext, associatedData []byte, keys KeyResolver) ([]byte, error) {
+	header, err := ParseCiphertextHeader(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	suite, err := CipherSuiteByID(header.Suite)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keys(header.Suite, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	ad := append(append([]byte(nil), ciphertext[:header.Size]...), associatedData...)
+	plain, err := suite.Decrypt(key, ciphertext[header.Size:], ad)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plain, nil
+}
@@ -0,0 +1,90 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+type reverseSuite struct{}
+
+func (s *reverseSuite) ID() SuiteID  { return 200 }
+func (s *reverseSuite) Name() string { return "test-reverse" }
+func (s *reverseSuite) Encrypt(key, plaintext, associatedData []byte) ([]byte, error) {
+	return XChaCha20Poly1305EncryptWithAD(key, reverse(plaintext), associatedData)
+}
+func (s *reverseSuite) Decrypt(key, ciphertext, associatedData []byte) ([]byte, error) {
+	plain, err := XChaCha20Poly1305DecryptWithAD(key, ciphertext, associatedData)
+	return reverse(plain), err
+}
+
+func TestVersionedCiphertext(t *testing.T) {
+	key, _ := New32ByteKey()
+	keys := func(suite SuiteID, keyID string) ([]byte, error) {
+		if keyID != "key-1" {
+			return nil, errors.New("unknown key")
+		}
+		return key, nil
+	}
+	msg := []byte("stored value")
+
+	for _, suite := range []SuiteID{SuiteAes256GCM, SuiteXChaCha20Poly1305, SuiteCurve25519XSalsa20Poly1305} {
+		encrypted, err := Encrypt(suite, key, "key-1", msg, []byte("row-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		header, err := ParseCiphertextHeader(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, suite, header.Suite)
+		assert.Equal(t, "key-1", header.KeyID)
+
+		decrypted, err := Decrypt(encrypted, []byte("row-1"), keys)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, decrypted)
+
+		_, err = Decrypt(encrypted, []byte("row-2"), keys)
+		assert.Equal(t, ErrDecryptionFailed, err)
+
+		// header is authenticated: changing the suite or key ID fails
+		modified := append([]byte(nil), encrypted...)
+		if suite == SuiteAes256GCM {
+			modified[4] = byte(SuiteXChaCha20Poly1305)
+		} else {
+			modified[4] = byte(SuiteAes256GCM)
+		}
+		_, err = Decrypt(modified, []byte("row-1"), keys)
+		assert.Error(t, err)
+		modified = append([]byte(nil), encrypted...)
+		modified[len(header.KeyID)+5] = '2'
+		_, err = Decrypt(modified, []byte("row-1"), keys)
+		assert.Error(t, err)
+	}
+
+	legacy, _ := Aes256Encrypt(key, msg)
+	_, err := Decrypt(legacy, nil, keys)
+	assert.Error(t, err)
+	_, err = Decrypt([]byte("MCR"), nil, keys)
+	assert.Equal(t, ErrInvalidCiphertextHeader, err)
+	_, err = Decrypt([]byte("MCR\x09\x01\x00"), nil, keys)
+	assert.Equal(t, ErrUnsupportedCiphertextVersion, err)
+	_, err = Encrypt(99, key, "key-1", msg, nil)
+	assert.Equal(t, ErrUnknownCipherSuite, err)
+}
+
+func TestRegisterCipherSuite(t *testing.T) {
+	assert.NoError(t, RegisterCipherSuite(&reverseSuite{}))
+	assert.Error(t, RegisterCipherSuite(&reverseSuite{}))
+
+	key, _ := New32ByteKey()
+	msg := make([]byte, 40)
+	rand.Read(msg)
+	encrypted, err := Encrypt(200, key, "", msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := Decrypt(encrypted, nil, func(SuiteID, string) ([]byte, error) { return key, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+}
@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// XChaCha20Poly1305Encrypt key must be 32 bytes long. Fast without AES hardware and the 192-bit
+// random nonce is safe for any number of messages with one key
+func XChaCha20Poly1305Encrypt(key []byte, plaintext []byte) ([]byte, error) {
+	return XChaCha20Poly1305EncryptWithAD(key, plaintext, nil)
+}
+
+// XChaCha20Poly1305EncryptWithAD encrypts plaintext and authenticates it together with associatedData (not encrypted
+// and not part of the output). The same associatedData is required for decryption
+func XChaCha20Poly1305EncryptWithAD(key []byte, plaintext []byte, associatedData []byte) ([]byte, error) {
+	if len(key) != chacha20poly1305.KeySize {
+		return nil, errors.New("Key must be 32 bytes long")
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func XChaCha20Poly1305Decrypt(key []byte, ciphertext []byte) ([]byte, error) {
+	return XChaCha20Poly1305DecryptWithAD(key, ciphertext, nil)
+}
+
+// XChaCha20Poly1305DecryptWithAD decrypts ciphertext created by XChaCha20Poly1305EncryptWithAD with the same associatedData
+func XChaCha20Poly1305DecryptWithAD(key []byte, ciphertext []byte, associatedData []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	return aead.Open(nil, nonce, ciphertext, associatedData)
+}
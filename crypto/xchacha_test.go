@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestEncDecXChaCha20Poly1305(t *testing.T) {
+	key, err := New32ByteKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("This should be encrypted")
+	ciphertext, err := XChaCha20Poly1305Encrypt(key, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 24+len(msg)+16, len(ciphertext))
+
+	plaintext, err := XChaCha20Poly1305Decrypt(key, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, msg, plaintext)
+
+	ciphertext[30] ^= 1
+	_, err = XChaCha20Poly1305Decrypt(key, ciphertext)
+	assert.Error(t, err)
+
+	_, err = XChaCha20Poly1305Decrypt(key, ciphertext[:10])
+	assert.Error(t, err)
+	_, err = XChaCha20Poly1305Encrypt(key[:16], msg)
+	assert.Error(t, err)
+}
+
+func TestEncDecXChaCha20Poly1305WithAD(t *testing.T) {
+	key, err := New32ByteKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("This should be encrypted")
+	ciphertext, err := XChaCha20Poly1305EncryptWithAD(key, msg, []byte("mailbox-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := XChaCha20Poly1305DecryptWithAD(key, ciphertext, []byte("mailbox-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, msg, plaintext)
+
+	_, err = XChaCha20Poly1305DecryptWithAD(key, ciphertext, []byte("mailbox-2"))
+	assert.Error(t, err)
+	_, err = XChaCha20Poly1305Decrypt(key, ciphertext)
+	assert.Error(t, err)
+}
@@ -1,19 +1,26 @@
 package mcrypt
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
+	"runtime"
+	"time"
 
 	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
 	"github.com/igorrendulic/mcrypt-sdk-go/utils"
 )
 
-func newKeyConfig(domain string, outputfile string) (*KeyConfig, error) {
+func newKeyConfig(domain string, outputfile string, passphrase string, seedMode bool) (*KeyConfig, error) {
 	cfg := KeyConfig{}
-	c, err := cfg.createConfig(domain, outputfile)
+	c, err := cfg.createConfig(domain, outputfile, passphrase, seedMode)
 	if err != nil {
 		return nil, err
 	}
@@ -22,6 +29,21 @@ func newKeyConfig(domain string, outputfile string) (*KeyConfig, error) {
 
 // LoadKeyConfigFile from filepath
 func loadKeyConfigFromFile(filePath string) (*KeyConfig, error) {
+	dat, err := readKeyFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := parseKeyConfig(dat)
+	if err != nil {
+		return nil, err
+	}
+	conf.filePath = filePath
+
+	return conf, nil
+}
+
+// readKeyFile reads key file after checking its permissions
+func readKeyFile(filePath string) ([]byte, error) {
 	exists, err := utils.Exists(filePath)
 	if err != nil {
 		return nil, err
@@ -29,21 +51,112 @@ func loadKeyConfigFromFile(filePath string) (*KeyConfig, error) {
 	if !exists {
 		return nil, errors.New("Config file not found")
 	}
-	dat, err := ioutil.ReadFile(filePath)
+	err = checkKeyFilePermissions(filePath)
 	if err != nil {
 		return nil, err
 	}
-	var conf *KeyConfig
-	err = json.Unmarshal(dat, &conf)
+	return ioutil.ReadFile(filePath)
+}
+
+// loadKeyConfigFromReader reads JSON key config from any source (secret volumes, network, embedded files)
+func loadKeyConfigFromReader(r io.Reader) (*KeyConfig, error) {
+	dat, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
-	conf.filePath = filePath
+	return parseKeyConfig(dat)
+}
 
+// loadKeyConfigFromEnv reads key config from environment variables. Whole JSON config in MCRYPT_KEY_CONFIG
+// takes precedence over the per field variables
+func loadKeyConfigFromEnv() (*KeyConfig, error) {
+	if dat, ok := os.LookupEnv(EnvKeyConfig); ok {
+		return parseKeyConfig([]byte(dat))
+	}
+	found := false
+	for _, env := range []string{EnvSeed, EnvDomain, EnvPub, EnvPriv, EnvPubC, EnvPrivC, EnvSecretKey} {
+		if os.Getenv(env) != "" {
+			found = true
+		}
+	}
+	if !found {
+		return nil, errors.New("Key config not found in environment")
+	}
+	conf := &KeyConfig{
+		Version:   KeyConfigVersion,
+		Seed:      os.Getenv(EnvSeed),
+		Domain:    os.Getenv(EnvDomain),
+		Pub:       os.Getenv(EnvPub),
+		Priv:      os.Getenv(EnvPriv),
+		PubC:      os.Getenv(EnvPubC),
+		PrivC:     os.Getenv(EnvPrivC),
+		SecretKey: os.Getenv(EnvSecretKey),
+	}
 	return conf, nil
 }
 
-func (config *KeyConfig) createConfig(domain, outputfilePath string) (*KeyConfig, error) {
+// ParseKeyConfig decodes JSON key config the same way the built-in key providers do: upgrades older schema
+// versions, decrypts encrypted configs with passphrase (not used for plaintext configs), verifies the checksum
+// and validates every field. Custom KeyProvider implementations (Vault, KMS, secret managers) return its result
+func ParseKeyConfig(dat []byte, passphrase string) (*KeyConfig, error) {
+	conf, err := parseKeyConfig(dat)
+	if err != nil {
+		return nil, err
+	}
+	err = conf.Decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	err = conf.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// parseKeyConfig decodes JSON key config, upgrading older schema versions on the way
+func parseKeyConfig(dat []byte) (*KeyConfig, error) {
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal(dat, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key config: %w", err)
+	}
+	if raw == nil {
+		return nil, errors.New("invalid key config: empty")
+	}
+	migrated, err := migrateKeyConfig(raw)
+	if err != nil {
+		return nil, err
+	}
+	if migrated {
+		dat, err = json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var conf KeyConfig
+	err = json.Unmarshal(dat, &conf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key config: %w", err)
+	}
+	conf.migrated = migrated
+	// encrypted configs are expanded and verified after decryption
+	if conf.Encrypted == nil {
+		err = conf.expandSeed()
+		if err != nil {
+			return nil, err
+		}
+		err = conf.verifyChecksum()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &conf, nil
+}
+
+// createConfig generates new keys and stores them to outputfilePath. If passphrase is not empty the private keys are stored encrypted.
+// In seed mode all keys are derived from a single random seed and only the seed (and public keys) are stored
+func (config *KeyConfig) createConfig(domain, outputfilePath, passphrase string, seedMode bool) (*KeyConfig, error) {
 	// check if keys for domain already exist in the local folder
 	exists, err := utils.Exists(outputfilePath)
 	if err != nil {
@@ -53,6 +166,45 @@ func (config *KeyConfig) createConfig(domain, outputfilePath string) (*KeyConfig
 		return nil, errors.New("File already exists! If you override it you might loose the keys")
 	}
 
+	var conf *KeyConfig
+	if seedMode {
+		conf, err = generateSeedConfigKeys()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		conf, err = generateConfigKeys()
+		if err != nil {
+			return nil, err
+		}
+		secretKey, err := crypto.New32ByteKey()
+		if err != nil {
+			return nil, err
+		}
+		conf.SecretKey = crypto.ConfigEncodeAesKey(secretKey)
+	}
+	conf.Version = KeyConfigVersion
+	conf.Domain = domain
+
+	toSave := conf
+	if passphrase != "" {
+		toSave, err = conf.encrypt(passphrase)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = toSave.save(outputfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Key succesfully generated for domain: %s, path: %s\n Please make sure to backup this file. You'll be needing it for user registration in your service!\n", domain, outputfilePath)
+
+	return conf, nil
+}
+
+// generateConfigKeys returns key config with only new random (encoded) keys set
+func generateConfigKeys() (*KeyConfig, error) {
 	priv, pub, err := crypto.GenerateEd25519Key(rand.Reader)
 	if err != nil {
 		fmt.Printf("failed to generate keys: %v\n", err)
@@ -79,52 +231,126 @@ func (config *KeyConfig) createConfig(domain, outputfilePath string) (*KeyConfig
 	encodedPrivate := crypto.ConfigEncodeKey(privBytes)
 	encodedPublic := crypto.ConfigEncodeKey(pubBytes)
 
-	conf := &KeyConfig{
-		Domain: domain,
-		Priv:   encodedPrivate,
-		Pub:    encodedPublic,
-		PrivC:  encodedPrivateCryptoKey,
-		PubC:   encodedPublicCryptoKey,
-	}
+	now := time.Now().UTC()
+	return &KeyConfig{
+		Priv:    encodedPrivate,
+		Pub:     encodedPublic,
+		PrivC:   encodedPrivateCryptoKey,
+		PubC:    encodedPublicCryptoKey,
+		Created: &now,
+	}, nil
+}
 
-	err = conf.save(outputfilePath)
+// checkKeyFilePermissions reports to OnInsecureKeyFile (or with StrictKeyFilePermissions refuses) key files readable by other users
+func checkKeyFilePermissions(filePath string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(filePath)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	fmt.Printf("Key succesfully generated for domain: %s, path: %s\n Please make sure to backup this file. You'll be needing it for user registration in your service!\n", domain, outputfilePath)
-
-	return conf, nil
+	if info.Mode().Perm()&0077 == 0 {
+		return nil
+	}
+	insecure := fmt.Errorf("%w: %s has permissions %s, it should only be readable by the owner (chmod 600)", ErrInsecureKeyFile, filePath, info.Mode().Perm())
+	if StrictKeyFilePermissions {
+		return insecure
+	}
+	if OnInsecureKeyFile != nil {
+		OnInsecureKeyFile(insecure)
+	}
+	return nil
 }
 
+// save writes key config atomically with owner only permissions. Checksum is recomputed for
+// plaintext configs, encrypted configs carry the checksum computed before encryption
 func (conf *KeyConfig) save(filePath string) error {
-
-	configJSON, err := json.Marshal(conf)
+	configJSON, err := conf.marshal()
 	if err != nil {
 		return err
 	}
-	err = ioutil.WriteFile(filePath, configJSON, 0644)
+	err = utils.WriteFileAtomic(filePath, configJSON, 0600)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (config *KeyConfig) validateKeyConf() error {
-	if config.Domain == "" {
-		return errors.New("Domain is missing in config file")
+// marshal returns JSON key config as stored in the key file
+func (conf *KeyConfig) marshal() ([]byte, error) {
+	toSave := *conf
+	if toSave.Encrypted == nil {
+		checksum, err := conf.checksum()
+		if err != nil {
+			return nil, err
+		}
+		toSave.Checksum = checksum
+	}
+	if toSave.Seed != "" {
+		// derived from the seed on load
+		toSave.Priv = ""
+		toSave.PrivC = ""
+		toSave.SecretKey = ""
+	}
+	return json.Marshal(&toSave)
+}
+
+// checksum is HMAC-SHA256 over the key config content (without version, checksum and encrypted fields)
+// keyed with a hash of the private keys. Detects truncated or hand edited key files
+func (conf *KeyConfig) checksum() (string, error) {
+	if conf.Priv == "" || conf.PrivC == "" {
+		return "", errors.New("checksum requires private keys")
 	}
-	if config.Priv == "" {
-		return errors.New("Private key is missing in config file")
+	content := *conf
+	content.Version = 0
+	content.Checksum = ""
+	content.Encrypted = nil
+	contentJSON, err := json.Marshal(&content)
+	if err != nil {
+		return "", err
 	}
-	if config.Pub == "" {
-		return errors.New("Public key is missing in config file")
+	key := sha256.Sum256([]byte("mcrypt key config checksum:" + conf.Priv + conf.PrivC))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write(contentJSON)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verifyChecksum checks checksum of a plaintext (or decrypted) config. Checksum may only be missing in
+// configs migrated from versions before it was required (it's added when the config is saved)
+func (conf *KeyConfig) verifyChecksum() error {
+	if conf.Checksum == "" {
+		if conf.migrated {
+			return nil
+		}
+		return ErrChecksumMissing
 	}
-	if config.PrivC == "" {
-		return errors.New("Private encryption key is missing in config file")
+	expected, err := conf.checksum()
+	if err != nil {
+		return err
 	}
-	if config.PubC == "" {
-		return errors.New("Public encryption key is missing in config file")
+	if !hmac.Equal([]byte(expected), []byte(conf.Checksum)) {
+		return ErrChecksumMismatch
 	}
 	return nil
 }
+
+// clone returns a deep copy of the key config
+func (conf *KeyConfig) clone() *KeyConfig {
+	c := *conf
+	if conf.Created != nil {
+		created := *conf.Created
+		c.Created = &created
+	}
+	if conf.Keyring != nil {
+		c.Keyring = &Keyring{
+			Sign: append([]KeyringEntry(nil), conf.Keyring.Sign...),
+			Enc:  append([]KeyringEntry(nil), conf.Keyring.Enc...),
+		}
+	}
+	if conf.Encrypted != nil {
+		encrypted := *conf.Encrypted
+		c.Encrypted = &encrypted
+	}
+	return &c
+}
@@ -0,0 +1,192 @@
+package mcrypt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestNewMCryptFromSources(t *testing.T) {
+	defer cleanupfiles("test-sources.json")
+
+	generated, err := GenerateRandomKeys("test.io", "test-sources.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	configJSON, err := json.Marshal(generated.keyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromFile, err := NewMCryptFromFile("test-sources.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromReader, err := NewMCryptFromReader(bytes.NewReader(configJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := NewMCryptFromJSON(configJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromConfig, err := NewMCryptFromConfig(generated.keyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range []*MCrypt{fromFile, fromReader, fromJSON, fromConfig} {
+		assert.True(t, m.SignPrivKey.Equals(generated.SignPrivKey))
+		assert.Equal(t, generated.EncPrivKey.Raw(), m.EncPrivKey.Raw())
+	}
+}
+
+func TestNewMCryptFromEnv(t *testing.T) {
+	defer cleanupfiles("test-env.json")
+
+	generated, err := GenerateRandomKeys("test.io", "test-env.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := generated.keyConfig
+
+	os.Setenv(EnvDomain, cfg.Domain)
+	os.Setenv(EnvPub, cfg.Pub)
+	os.Setenv(EnvPriv, cfg.Priv)
+	os.Setenv(EnvPubC, cfg.PubC)
+	os.Setenv(EnvPrivC, cfg.PrivC)
+	defer func() {
+		for _, env := range []string{EnvDomain, EnvPub, EnvPriv, EnvPubC, EnvPrivC} {
+			os.Unsetenv(env)
+		}
+	}()
+
+	m, err := NewMCryptFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, m.SignPrivKey.Equals(generated.SignPrivKey))
+	assert.Equal(t, generated.EncPrivKey.Raw(), m.EncPrivKey.Raw())
+}
+
+func TestNewMCryptErrors(t *testing.T) {
+	_, err := NewMCryptFromFile("does-not-exist.json")
+	assert.Error(t, err)
+
+	_, err = NewMCryptFromJSON([]byte("{not json"))
+	assert.Error(t, err)
+
+	_, err = NewMCryptFromJSON([]byte(`{"domain":"test.io"}`))
+	assert.Error(t, err)
+
+	_, err = NewMCryptFromConfig(nil)
+	assert.Error(t, err)
+}
+
+func TestKeyFileWrittenOwnerOnly(t *testing.T) {
+	defer cleanupfiles("test-perm.json")
+
+	_, err := GenerateRandomKeys("test.io", "test-perm.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat("test-perm.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	err = os.Chmod("test-perm.json", 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// warning logged by default
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	_, err = NewMCryptFromFile("test-perm.json")
+	log.SetOutput(os.Stderr)
+	assert.NoError(t, err)
+	assert.Contains(t, logged.String(), "test-perm.json has permissions -rw-r--r--")
+
+	var reported error
+	defaultHook := OnInsecureKeyFile
+	OnInsecureKeyFile = func(err error) { reported = err }
+	defer func() { OnInsecureKeyFile = defaultHook }()
+	_, err = NewMCryptFromFile("test-perm.json")
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(reported, ErrInsecureKeyFile))
+
+	StrictKeyFilePermissions = true
+	defer func() { StrictKeyFilePermissions = false }()
+	_, err = NewMCryptFromFile("test-perm.json")
+	assert.True(t, errors.Is(err, ErrInsecureKeyFile))
+}
+
+func TestKeyFileChecksum(t *testing.T) {
+	defer cleanupfiles("test-checksum.json", "test-checksum-pass.json")
+
+	generated, err := GenerateRandomKeys("test.io", "test-checksum.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, "", generated.keyConfig.Checksum)
+
+	// hand edited domain
+	content, err := ioutil.ReadFile("test-checksum.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := bytes.Replace(content, []byte(`"domain":"test.io"`), []byte(`"domain":"evil.io"`), 1)
+	err = ioutil.WriteFile("test-checksum.json", edited, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewMCryptFromFile("test-checksum.json")
+	assert.Equal(t, ErrChecksumMismatch, err)
+
+	// removed checksum
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(content, &raw))
+	delete(raw, "checksum")
+	stripped, err := json.Marshal(raw)
+	assert.NoError(t, err)
+	_, err = NewMCryptFromJSON(stripped)
+	assert.Equal(t, ErrChecksumMissing, err)
+	// accepted only when migrating from a version before checksums were required
+	raw["version"] = 2
+	stripped, err = json.Marshal(raw)
+	assert.NoError(t, err)
+	_, err = NewMCryptFromJSON(stripped)
+	assert.NoError(t, err)
+
+	// truncated file
+	err = ioutil.WriteFile("test-checksum.json", content[:len(content)/2], 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewMCryptFromFile("test-checksum.json")
+	assert.Error(t, err)
+
+	// encrypted config is verified after decryption
+	_, err = GenerateRandomKeysWithPassphrase("test.io", "test-checksum-pass.json", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err = ioutil.ReadFile("test-checksum-pass.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited = bytes.Replace(content, []byte(`"domain":"test.io"`), []byte(`"domain":"evil.io"`), 1)
+	err = ioutil.WriteFile("test-checksum-pass.json", edited, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewMCryptFromFileWithPassphrase("test-checksum-pass.json", "secret passphrase")
+	assert.Equal(t, ErrChecksumMismatch, err)
+}
@@ -0,0 +1,57 @@
+package mcrypt
+
+import (
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+)
+
+// DeriveKeys deterministically derives signing and encryption subkeys for a path such as users/<id>/mail
+// (SLIP-0010 hardened derivation from the seed of the derivation root signing key). Subkeys don't need to be
+// stored, the same path always returns the same keys. The root is the original domain signing key: RotateKeys
+// keeps it in the keyring (KeyConfig.DeriveRoot) so derived subkeys don't change when domain keys rotate.
+// Revoking the root doesn't change derived subkeys either, use DeriveKeysWithKey to move to another root
+func (m *MCrypt) DeriveKeys(path string) (*DerivedKeys, error) {
+	m.mu.RLock()
+	root := m.keyConfig.DeriveRoot
+	if root == "" {
+		root = m.signKeys[0].ID
+	}
+	m.mu.RUnlock()
+	return m.DeriveKeysWithKey(root, path)
+}
+
+// DeriveKeysWithKey derives subkeys for a path from the signing key with keyID (active or keyring key, see
+// SignKeys). Use it to regenerate subkeys derived from a specific key (DerivedKeys.KeyID)
+func (m *MCrypt) DeriveKeysWithKey(keyID, path string) (*DerivedKeys, error) {
+	m.mu.RLock()
+	var root *SignKey
+	for _, k := range m.signKeys {
+		if k.ID == keyID {
+			root = k
+			break
+		}
+	}
+	m.mu.RUnlock()
+	if root == nil {
+		return nil, ErrKeyNotFound
+	}
+	master, err := crypto.NewHDMasterKeyFromEd25519(root.PrivKey)
+	if err != nil {
+		return nil, err
+	}
+	child, err := master.DerivePath(path)
+	if err != nil {
+		return nil, err
+	}
+	priv, pub, privC, pubC, err := child.Keys()
+	if err != nil {
+		return nil, err
+	}
+	return &DerivedKeys{
+		Path:        path,
+		KeyID:       keyID,
+		SignPrivKey: priv,
+		SignPubKey:  pub,
+		EncPrivKey:  privC,
+		EncPubKey:   pubC,
+	}, nil
+}
@@ -0,0 +1,101 @@
+package mcrypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func TestDeriveKeys(t *testing.T) {
+	seed := []byte("0123456789abcdef0123456789abcdef")
+	m, err := NewMCryptFromSeed("test.io", seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, err := m.DeriveKeys("users/alice/mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, alice.SignPrivKey.GetPublic().Equals(alice.SignPubKey))
+	assert.False(t, alice.SignPubKey.Equals(m.SignPubKey))
+
+	// regenerated on demand from the same domain keys
+	restored, err := NewMCryptFromSeed("test.io", seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := restored.DeriveKeys("users/alice/mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, alice.SignPrivKey.Equals(again.SignPrivKey))
+	assert.Equal(t, alice.EncPrivKey.Raw(), again.EncPrivKey.Raw())
+
+	bob, err := m.DeriveKeys("users/bob/mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, alice.SignPubKey.Equals(bob.SignPubKey))
+
+	msg := []byte("per mailbox encryption")
+	encrypted, err := alice.EncPrivKey.Encrypt(bob.EncPubKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := bob.EncPrivKey.Decrypt(alice.EncPubKey, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, msg, decrypted)
+
+	_, err = m.DeriveKeys("")
+	assert.Equal(t, crypto.ErrInvalidDerivationPath, err)
+}
+
+func TestDeriveKeysStableAcrossRotation(t *testing.T) {
+	defer cleanupfiles("test-derive-rotate.json")
+
+	m, err := GenerateRandomKeys("test.io", "test-derive-rotate.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := m.DeriveKeys("users/alice/mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, m.RotateKeys())
+	assert.NoError(t, m.RotateKeys())
+	assert.NoError(t, m.SaveKeyConfig("", ""))
+
+	loaded, err := NewMCryptFromFile("test-derive-rotate.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mc := range []*MCrypt{m, loaded} {
+		after, err := mc.DeriveKeys("users/alice/mail")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, before.KeyID, after.KeyID)
+		assert.True(t, before.SignPrivKey.Equals(after.SignPrivKey))
+		assert.Equal(t, before.EncPrivKey.Raw(), after.EncPrivKey.Raw())
+	}
+
+	// explicit root
+	active, err := m.DeriveKeysWithKey(m.SignKeys()[0].ID, "users/alice/mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, active.SignPubKey.Equals(before.SignPubKey))
+	_, err = m.DeriveKeysWithKey("unknown", "users/alice/mail")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	// deriveRoot must reference a keyring signing key
+	cfg := m.keyConfig.clone()
+	cfg.DeriveRoot = "unknown"
+	var verr *ValidationError
+	assert.True(t, errors.As(cfg.Validate(), &verr))
+	assert.NotNil(t, verr.Field("deriveRoot"))
+}
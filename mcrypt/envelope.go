@@ -0,0 +1,27 @@
+package mcrypt
+
+import (
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+)
+
+// SealEnvelope encrypts plaintext once for all recipients (see crypto.SealEnvelope). Recipient list is hidden
+func (m *MCrypt) SealEnvelope(recipients []crypto.PubCKey, plaintext, associatedData []byte) ([]byte, error) {
+	return crypto.SealEnvelope(recipients, plaintext, associatedData)
+}
+
+// OpenEnvelope decrypts envelope addressed to the domain, trying the active and then the retired encryption keys
+func (m *MCrypt) OpenEnvelope(envelope, associatedData []byte) ([]byte, error) {
+	m.mu.RLock()
+	encKeys := m.encKeys
+	m.mu.RUnlock()
+	for _, k := range encKeys {
+		if k.State == KeyStateRevoked {
+			continue
+		}
+		plain, err := crypto.OpenEnvelope(k.PrivKey, envelope, associatedData)
+		if err != crypto.ErrNotRecipient {
+			return plain, err
+		}
+	}
+	return nil, crypto.ErrNotRecipient
+}
@@ -0,0 +1,49 @@
+package mcrypt
+
+import (
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func TestEnvelopeRecipients(t *testing.T) {
+	sender, err := NewMCryptFromSeed("sender.io", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, err := NewMCryptFromSeed("alice.io", []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewMCryptFromSeed("bob.io", []byte("abcdef0123456789abcdef0123456789"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("one mail, many recipients")
+
+	envelope, err := sender.SealEnvelope([]crypto.PubCKey{alice.EncPubKey, bob.EncPubKey}, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bob.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, recipient := range []*MCrypt{alice, bob} {
+		plain, err := recipient.OpenEnvelope(envelope, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, plain)
+	}
+	_, err = sender.OpenEnvelope(envelope, nil)
+	assert.Equal(t, crypto.ErrNotRecipient, err)
+
+	// recipient key IDs match keyring IDs
+	envelope, err = crypto.SealEnvelopeWithOptions([]crypto.PubCKey{alice.EncPubKey}, msg, nil, crypto.EnvelopeOptions{RevealRecipients: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := crypto.EnvelopeRecipients(envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{alice.EncKeys()[0].ID}, ids)
+}
@@ -0,0 +1,36 @@
+package mcrypt
+
+import "errors"
+
+var (
+	// ErrPassphraseRequired is returned when loading an encrypted key config without a passphrase
+	ErrPassphraseRequired = errors.New("key config is encrypted, passphrase required")
+	// ErrWrongPassphrase is returned when the passphrase doesn't decrypt the key config
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted key config")
+	// ErrUnsupportedKeyConfigVersion is returned for key config files newer than this library
+	ErrUnsupportedKeyConfigVersion = errors.New("unsupported key config version, upgrade mcrypt-sdk-go")
+	// ErrKeyNotFound is returned when key ID is not in the keyring
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrRevokeActiveKey is returned when trying to revoke the active key
+	ErrRevokeActiveKey = errors.New("active key can't be revoked, rotate keys first")
+	// ErrSecretKeyMissing is returned for symmetric encryption when key config has no secretKey
+	ErrSecretKeyMissing = errors.New("secret key is missing in config file")
+	// ErrKDFParamsTooHigh is returned for encrypted key configs with key derivation cost above MaxArgon2idParams
+	ErrKDFParamsTooHigh = errors.New("key derivation parameters exceed MaxArgon2idParams")
+	// ErrChecksumMismatch is returned when key config content doesn't match its checksum
+	ErrChecksumMismatch = errors.New("key config checksum mismatch, file is corrupted or was modified")
+	// ErrChecksumMissing is returned for key configs without the checksum required since key config version 3
+	ErrChecksumMissing = errors.New("key config checksum missing")
+	// ErrInsecureKeyFile is returned with StrictKeyFilePermissions for key files readable by other users
+	ErrInsecureKeyFile = errors.New("key file is readable by other users")
+	// ErrNoSeed is returned for seed operations (e.g. mnemonic export) on a key config that isn't in single seed mode
+	ErrNoSeed = errors.New("key config has no seed")
+	// ErrNoKeyProvider is returned when reloading keys of MCrypt created without a key provider
+	ErrNoKeyProvider = errors.New("keys were not loaded from a key provider")
+	// ErrInvalidSignedEnvelope is returned for malformed signed envelopes
+	ErrInvalidSignedEnvelope = errors.New("invalid signed envelope")
+	// ErrUnsupportedEnvelopeVersion is returned for signed envelopes newer than this library
+	ErrUnsupportedEnvelopeVersion = errors.New("unsupported signed envelope version, upgrade mcrypt-sdk-go")
+	// ErrInvalidSignature is returned when the sender signature of a signed envelope doesn't verify
+	ErrInvalidSignature = errors.New("invalid sender signature")
+)
@@ -0,0 +1,49 @@
+package mcrypt
+
+import (
+	"encoding/json"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+)
+
+// JWKS returns the domain public JSON Web Key Set: active and retired signing (Ed25519) and
+// encryption (X25519) public keys. Revoked keys are left out. Key IDs (kid) are the keyring IDs (SignKey.ID,
+// EncKey.ID, KeyringEntry.ID) so keys in the JWKS, the keyring and versioned ciphertext headers share one ID.
+// RFC 7638 thumbprints are available from JWK.Thumbprint
+func (m *MCrypt) JWKS() (*crypto.JWKS, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	jwks := &crypto.JWKS{Keys: []*crypto.JWK{}}
+	for _, k := range m.signKeys {
+		if k.State == KeyStateRevoked {
+			continue
+		}
+		jwk, err := crypto.Ed25519PublicKeyToJWK(k.PubKey)
+		if err != nil {
+			return nil, err
+		}
+		jwk.Kid = k.ID
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	for _, k := range m.encKeys {
+		if k.State == KeyStateRevoked {
+			continue
+		}
+		jwk, err := crypto.X25519PublicKeyToJWK(k.PubKey)
+		if err != nil {
+			return nil, err
+		}
+		jwk.Kid = k.ID
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks, nil
+}
+
+// JWKSJSON returns JWKS as JSON document (e.g. to serve on /.well-known/jwks.json)
+func (m *MCrypt) JWKSJSON() ([]byte, error) {
+	jwks, err := m.JWKS()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jwks)
+}
@@ -0,0 +1,63 @@
+package mcrypt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func TestDomainJWKS(t *testing.T) {
+	defer cleanupfiles("test-jwks.json")
+
+	mcrypt, err := GenerateRandomKeys("test.io", "test-jwks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mcrypt.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwksJSON, err := mcrypt.JWKSJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jwks crypto.JWKS
+	err = json.Unmarshal(jwksJSON, &jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 4, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		assert.Equal(t, "", jwk.D)
+	}
+	// same key IDs as the keyring
+	assert.Equal(t, mcrypt.SignKeys()[0].ID, jwks.Keys[0].Kid)
+	assert.Equal(t, mcrypt.SignKeys()[1].ID, jwks.Keys[1].Kid)
+	assert.Equal(t, mcrypt.EncKeys()[0].ID, jwks.Keys[2].Kid)
+	assert.Equal(t, mcrypt.EncKeys()[1].ID, jwks.Keys[3].Kid)
+
+	signPub, err := jwks.Keys[0].Ed25519PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signKey, encKey := mcrypt.ActiveKeys()
+	assert.True(t, signPub.Equals(signKey.PubKey))
+	encPub, err := jwks.Keys[2].X25519PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, encKey.PubKey.Raw(), encPub.Raw())
+
+	err = mcrypt.RevokeKey(mcrypt.SignKeys()[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksAfterRevoke, err := mcrypt.JWKS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, len(jwksAfterRevoke.Keys))
+}
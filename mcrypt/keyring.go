@@ -0,0 +1,410 @@
+package mcrypt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+)
+
+// keyID identifies a key by the first 8 bytes of sha256 of its raw public key
+func keyID(rawPub []byte) string {
+	h := sha256.Sum256(rawPub)
+	return hex.EncodeToString(h[:8])
+}
+
+func signKeyID(pub crypto.PubKey) (string, error) {
+	raw, err := pub.Raw()
+	if err != nil {
+		return "", err
+	}
+	return keyID(raw), nil
+}
+
+func encKeyID(pub crypto.PubCKey) string {
+	return keyID(pub.Raw()[:])
+}
+
+func decodeSignKeys(pub, priv string) (crypto.PrivKey, crypto.PubKey, error) {
+	privSignKey, err := crypto.ConfigDecodeKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubSignKey, err := crypto.ConfigDecodeKey(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	signPrivKey, err := crypto.UnmarshalEd25519PrivateKey(privSignKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	signPubKey, err := crypto.UnmarshalPublicKey(pubSignKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signPrivKey, signPubKey, nil
+}
+
+func decodeEncKeys(pub, priv string) (crypto.PrivCKey, crypto.PubCKey, error) {
+	privEncKey, err := crypto.ConfigDecodeEncryptKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	pubEncKey, err := crypto.ConfigDecodeEncryptKey(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &crypto.Curve25519PrivateKey{Key: privEncKey}, &crypto.Curve25519PublicKey{Key: pubEncKey}, nil
+}
+
+// applyKeyring loads active keys followed by the keyring keys. Active keys must already be applied
+func (m *MCrypt) applyKeyring(config *KeyConfig) error {
+	var created time.Time
+	if config.Created != nil {
+		created = *config.Created
+	}
+	activeSignID, err := signKeyID(m.SignPubKey)
+	if err != nil {
+		return err
+	}
+	signKeys := []*SignKey{{
+		ID:      activeSignID,
+		Created: created,
+		State:   KeyStateActive,
+		PrivKey: m.SignPrivKey,
+		PubKey:  m.SignPubKey,
+	}}
+	encKeys := []*EncKey{{
+		ID:      encKeyID(m.EncPubKey),
+		Created: created,
+		State:   KeyStateActive,
+		PrivKey: m.EncPrivKey,
+		PubKey:  m.EncPubKey,
+	}}
+
+	if config.Keyring != nil {
+		for _, entry := range config.Keyring.Sign {
+			priv, pub, err := decodeSignKeys(entry.Pub, entry.Priv)
+			if err != nil {
+				return err
+			}
+			signKeys = append(signKeys, &SignKey{
+				ID:      entry.ID,
+				Created: entry.Created,
+				State:   entry.State,
+				PrivKey: priv,
+				PubKey:  pub,
+			})
+		}
+		for _, entry := range config.Keyring.Enc {
+			priv, pub, err := decodeEncKeys(entry.Pub, entry.Priv)
+			if err != nil {
+				return err
+			}
+			encKeys = append(encKeys, &EncKey{
+				ID:      entry.ID,
+				Created: entry.Created,
+				State:   entry.State,
+				PrivKey: priv,
+				PubKey:  pub,
+			})
+		}
+	}
+
+	m.signKeys = signKeys
+	m.encKeys = encKeys
+	return nil
+}
+
+// SignKeys returns all signing keys, active key first
+func (m *MCrypt) SignKeys() []*SignKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.signKeys
+}
+
+// EncKeys returns all encryption keys, active key first
+func (m *MCrypt) EncKeys() []*EncKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.encKeys
+}
+
+// SignKey returns signing key by ID
+func (m *MCrypt) SignKey(id string) (*SignKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, k := range m.signKeys {
+		if k.ID == id {
+			return k, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// EncKey returns encryption key by ID
+func (m *MCrypt) EncKey(id string) (*EncKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, k := range m.encKeys {
+		if k.ID == id {
+			return k, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// Sign signs the message with the active signing key
+func (m *MCrypt) Sign(msg []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.signKeys[0].PrivKey.Sign(msg)
+}
+
+// SignWithContext signs msg with the active key bound to context (Ed25519ctx, e.g. crypto.SignContextMessage)
+func (m *MCrypt) SignWithContext(msg []byte, context string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	signer, ok := m.signKeys[0].PrivKey.(crypto.ContextSigner)
+	if !ok {
+		return nil, crypto.ErrBadKeyType
+	}
+	return signer.SignWithContext(msg, context)
+}
+
+// VerifyWithContext checks signature created by SignWithContext against the active and retired signing keys
+func (m *MCrypt) VerifyWithContext(msg, sig []byte, context string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, k := range m.signKeys {
+		if k.State == KeyStateRevoked {
+			continue
+		}
+		verifier, ok := k.PubKey.(crypto.ContextVerifier)
+		if !ok {
+			return false, crypto.ErrBadKeyType
+		}
+		ok, err := verifier.VerifyWithContext(msg, sig, context)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Verify checks signature against the active and retired signing keys
+func (m *MCrypt) Verify(msg, sig []byte) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, k := range m.signKeys {
+		if k.State == KeyStateRevoked {
+			continue
+		}
+		ok, err := k.PubKey.Verify(msg, sig)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Encrypt encrypts payload for the recipient with the active encryption key
+func (m *MCrypt) Encrypt(recipientPublicKey crypto.PubCKey, payload []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	active := m.encKeys[0]
+	sharedKey := m.sharedKeys.get(active.ID, active.PrivKey, recipientPublicKey)
+	return crypto.EncryptWithSharedKey(&sharedKey, payload)
+}
+
+// EncryptWithAD encrypts payload for the recipient bound to associatedData (e.g. message ID or Key.Bytes())
+func (m *MCrypt) EncryptWithAD(recipientPublicKey crypto.PubCKey, payload, associatedData []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return crypto.EncryptWithAD(m.encKeys[0].PrivKey, recipientPublicKey, payload, associatedData)
+}
+
+// Decrypt decrypts payload from the sender trying the active and then the retired encryption keys
+func (m *MCrypt) Decrypt(senderPublicKey crypto.PubCKey, encryptedPayload []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, k := range m.encKeys {
+		if k.State == KeyStateRevoked {
+			continue
+		}
+		sharedKey := m.sharedKeys.get(k.ID, k.PrivKey, senderPublicKey)
+		plain, err := crypto.DecryptWithSharedKey(&sharedKey, encryptedPayload)
+		if err == nil {
+			return plain, nil
+		}
+	}
+	return nil, crypto.ErrDecryptionFailed
+}
+
+// DecryptWithAD decrypts payload created by EncryptWithAD with the same associatedData, trying the active
+// and then the retired encryption keys
+func (m *MCrypt) DecryptWithAD(senderPublicKey crypto.PubCKey, encryptedPayload, associatedData []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, k := range m.encKeys {
+		if k.State == KeyStateRevoked {
+			continue
+		}
+		plain, err := crypto.DecryptWithAD(k.PrivKey, senderPublicKey, encryptedPayload, associatedData)
+		if err == nil {
+			return plain, nil
+		}
+	}
+	return nil, crypto.ErrDecryptionFailed
+}
+
+// SealAnonymous encrypts msg to the recipient without a sender key (see crypto.SealAnonymous)
+func (m *MCrypt) SealAnonymous(recipientPublicKey crypto.PubCKey, msg []byte) ([]byte, error) {
+	return crypto.SealAnonymous(recipientPublicKey, msg)
+}
+
+// OpenAnonymous decrypts message sealed to the domain encryption key, trying the active and then the retired keys
+func (m *MCrypt) OpenAnonymous(sealed []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, k := range m.encKeys {
+		if k.State == KeyStateRevoked {
+			continue
+		}
+		plain, err := crypto.OpenAnonymous(k.PrivKey, sealed)
+		if err == nil {
+			return plain, nil
+		}
+	}
+	return nil, crypto.ErrDecryptionFailed
+}
+
+// RotateKeys generates new active signing and encryption keys. Previous active keys are kept in the
+// keyring as retired (verify and decrypt only). Call SaveKeyConfig to persist the keyring.
+// Single seed configs switch to regular mode (keys stored explicitly) so the secret key stays the same
+func (m *MCrypt) RotateKeys() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// changes are made on a copy and swapped in only when the new keys load
+	cfg := m.keyConfig.clone()
+	fresh, err := generateConfigKeys()
+	if err != nil {
+		return err
+	}
+
+	var created time.Time
+	if cfg.Created != nil {
+		created = *cfg.Created
+	}
+	signID := m.signKeys[0].ID
+	if cfg.Keyring == nil {
+		cfg.Keyring = &Keyring{}
+	}
+	if cfg.DeriveRoot == "" {
+		// derived subkeys stay the same after rotation
+		cfg.DeriveRoot = signID
+	}
+	// newest first so Decrypt and Verify try the most likely keys first
+	cfg.Keyring.Sign = append([]KeyringEntry{{
+		ID:      signID,
+		Created: created,
+		State:   KeyStateRetired,
+		Pub:     cfg.Pub,
+		Priv:    cfg.Priv,
+	}}, cfg.Keyring.Sign...)
+	cfg.Keyring.Enc = append([]KeyringEntry{{
+		ID:      m.encKeys[0].ID,
+		Created: created,
+		State:   KeyStateRetired,
+		Pub:     cfg.PubC,
+		Priv:    cfg.PrivC,
+	}}, cfg.Keyring.Enc...)
+
+	cfg.Pub = fresh.Pub
+	cfg.Priv = fresh.Priv
+	cfg.PubC = fresh.PubC
+	cfg.PrivC = fresh.PrivC
+	cfg.Created = fresh.Created
+	cfg.Seed = ""
+	cfg.MnemonicEntropy = ""
+
+	return m.replaceConfig(cfg)
+}
+
+// RevokeKey marks keyring key (signing or encryption) as revoked so it's no longer used for
+// verification or decryption. Active keys can't be revoked, rotate them first
+func (m *MCrypt) RevokeKey(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cfg := m.keyConfig.clone()
+	if len(m.signKeys) > 0 && m.signKeys[0].ID == id || len(m.encKeys) > 0 && m.encKeys[0].ID == id {
+		return ErrRevokeActiveKey
+	}
+	if cfg.Keyring == nil {
+		return ErrKeyNotFound
+	}
+	found := false
+	for i := range cfg.Keyring.Sign {
+		if cfg.Keyring.Sign[i].ID == id {
+			cfg.Keyring.Sign[i].State = KeyStateRevoked
+			found = true
+		}
+	}
+	for i := range cfg.Keyring.Enc {
+		if cfg.Keyring.Enc[i].ID == id {
+			cfg.Keyring.Enc[i].State = KeyStateRevoked
+			found = true
+		}
+	}
+	if !found {
+		return ErrKeyNotFound
+	}
+	return m.replaceConfig(cfg)
+}
+
+// replaceConfig loads keys from cfg and swaps them in only when all of them are valid. Callers hold m.mu
+func (m *MCrypt) replaceConfig(cfg *KeyConfig) error {
+	fresh, err := NewMCryptFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	m.setKeys(fresh)
+	return nil
+}
+
+// SaveKeyConfig writes the key config (including the keyring) to filePath. Empty filePath
+// writes back to the file the config was loaded from. If passphrase is not empty private keys are encrypted.
+// Configs loaded from an encrypted key file are never written in plaintext, they require the passphrase
+// (ErrPassphraseRequired)
+func (m *MCrypt) SaveKeyConfig(filePath, passphrase string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cfg := m.keyConfig
+	if cfg.Encrypted != nil && passphrase == "" {
+		return ErrPassphraseRequired
+	}
+	if filePath == "" {
+		filePath = cfg.filePath
+	}
+	if filePath == "" {
+		return errors.New("key config file path is missing")
+	}
+	if passphrase != "" {
+		encrypted, err := cfg.encrypt(passphrase)
+		if err != nil {
+			return err
+		}
+		return encrypted.save(filePath)
+	}
+	plain := *cfg
+	plain.Encrypted = nil
+	return plain.save(filePath)
+}
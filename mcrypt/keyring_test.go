@@ -0,0 +1,224 @@
+package mcrypt
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func TestRotateKeys(t *testing.T) {
+	defer cleanupfiles("test-rotate.json", "test-peer.json")
+
+	mcrypt, err := GenerateRandomKeys("test.io", "test-rotate.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := GenerateRandomKeys("peer.io", "test-peer.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("signed and encrypted before rotation")
+	oldSignature, err := mcrypt.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldEncrypted, err := peer.Encrypt(mcrypt.EncPubKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSignID := mcrypt.SignKeys()[0].ID
+	oldEncID := mcrypt.EncKeys()[0].ID
+
+	err = mcrypt.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mcrypt.SaveKeyConfig("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewMCryptFromFile("test-rotate.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(reloaded.SignKeys()))
+	assert.Equal(t, 2, len(reloaded.EncKeys()))
+	assert.Equal(t, KeyStateActive, reloaded.SignKeys()[0].State)
+	assert.NotEqual(t, oldSignID, reloaded.SignKeys()[0].ID)
+
+	retired, err := reloaded.SignKey(oldSignID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, KeyStateRetired, retired.State)
+
+	isValid, err := reloaded.Verify(msg, oldSignature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, isValid)
+	decrypted, err := reloaded.Decrypt(peer.EncPubKey, oldEncrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, msg, decrypted)
+
+	// new operations use the new active key
+	newEncrypted, err := reloaded.Encrypt(peer.EncPubKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = peer.EncPrivKey.Decrypt(mcrypt.EncKeys()[0].PubKey, newEncrypted)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ErrRevokeActiveKey, reloaded.RevokeKey(reloaded.SignKeys()[0].ID))
+	assert.Equal(t, ErrKeyNotFound, reloaded.RevokeKey("unknown"))
+	assert.NoError(t, reloaded.RevokeKey(oldSignID))
+	assert.NoError(t, reloaded.RevokeKey(oldEncID))
+
+	isValid, err = reloaded.Verify(msg, oldSignature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, isValid)
+	_, err = reloaded.Decrypt(peer.EncPubKey, oldEncrypted)
+	assert.Error(t, err)
+}
+
+func TestRotateKeysWithPassphrase(t *testing.T) {
+	defer cleanupfiles("test-rotate-pass.json")
+
+	mcrypt, err := GenerateRandomKeysWithPassphrase("test.io", "test-rotate-pass.json", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mcrypt.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// private keys of an encrypted key file are never written in plaintext
+	before, err := ioutil.ReadFile("test-rotate-pass.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ErrPassphraseRequired, mcrypt.SaveKeyConfig("", ""))
+	after, err := ioutil.ReadFile("test-rotate-pass.json")
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	err = mcrypt.SaveKeyConfig("", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadKeyConfigFromFile("test-rotate-pass.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "", cfg.Keyring.Sign[0].Priv)
+	assert.Equal(t, "", cfg.Keyring.Enc[0].Priv)
+
+	reloaded, err := NewMCryptFromFileWithPassphrase("test-rotate-pass.json", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, reloaded.SignKeys()[1].PrivKey.Equals(mcrypt.SignKeys()[1].PrivKey))
+	assert.Equal(t, mcrypt.EncKeys()[1].PrivKey.Raw(), reloaded.EncKeys()[1].PrivKey.Raw())
+}
+
+func TestEncryptWithAD(t *testing.T) {
+	alice, err := NewMCryptFromSeed("alice.io", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewMCryptFromSeed("bob.io", []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rowKey := NewKey("row-1")
+	msg := []byte("value stored under row-1")
+
+	encrypted, err := alice.EncryptWithAD(bob.EncPubKey, msg, rowKey.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bob.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := bob.DecryptWithAD(alice.EncPubKey, encrypted, rowKey.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+
+	_, err = bob.DecryptWithAD(alice.EncPubKey, encrypted, NewKey("row-2").Bytes())
+	assert.Equal(t, crypto.ErrDecryptionFailed, err)
+}
+
+func TestSealAnonymous(t *testing.T) {
+	domain, err := NewMCryptFromSeed("test.io", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("anonymous message to the domain")
+	sealed, err := crypto.SealAnonymous(domain.EncPubKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = domain.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := domain.OpenAnonymous(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, opened)
+
+	sealed, err = domain.SealAnonymous(domain.EncPubKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err = domain.OpenAnonymous(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, opened)
+}
+
+func TestSignWithContext(t *testing.T) {
+	m, err := NewMCryptFromSeed("test.io", []byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+
+	sig, err := m.SignWithContext([]byte("config"), crypto.SignContextKeyConfig)
+	assert.NoError(t, err)
+	assert.NoError(t, m.RotateKeys())
+
+	valid, err := m.VerifyWithContext([]byte("config"), sig, crypto.SignContextKeyConfig)
+	assert.NoError(t, err)
+	assert.True(t, valid, "retired key signature")
+	valid, err = m.VerifyWithContext([]byte("config"), sig, crypto.SignContextHandshake)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	valid, err = m.Verify([]byte("config"), sig)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestRotateKeysFailureKeepsKeys(t *testing.T) {
+	m, err := NewMCryptFromSeed("test.io", []byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+	assert.NoError(t, m.RotateKeys())
+	retiredID := m.keyConfig.Keyring.Enc[0].ID
+	pub := m.keyConfig.Pub
+
+	// corrupted keyring entry makes loading the new config fail
+	m.keyConfig.Keyring.Sign[0].Pub = "corrupted"
+	assert.Error(t, m.RotateKeys())
+	assert.Equal(t, pub, m.keyConfig.Pub)
+	assert.Equal(t, 1, len(m.keyConfig.Keyring.Sign))
+	assert.Equal(t, 2, len(m.signKeys))
+
+	assert.Error(t, m.RevokeKey(retiredID))
+	assert.Equal(t, KeyStateRetired, m.keyConfig.Keyring.Enc[0].State)
+	assert.Equal(t, KeyStateRetired, m.encKeys[1].State)
+}
@@ -4,87 +4,198 @@ import (
 	"crypto/ed25519"
 	"encoding/base64"
 	"errors"
+	"io"
 
 	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
 )
 
 /**
 * Main class implementing ed25519 cruve25519 and aes256/aes512 encrrytion algorithms
+* Panics if the key file can't be loaded. Use NewMCryptFromFile to handle the error
 **/
 func NewMCrypt(pathToJSONKey string) *MCrypt {
-	cfg, err := loadKeyConfigFromFile(pathToJSONKey)
+	return NewMCryptWithPassphrase(pathToJSONKey, "")
+}
+
+/**
+* Same as NewMCrypt but for key files generated with GenerateRandomKeysWithPassphrase
+**/
+func NewMCryptWithPassphrase(pathToJSONKey, passphrase string) *MCrypt {
+	m, err := NewMCryptFromFileWithPassphrase(pathToJSONKey, passphrase)
 	if err != nil {
 		panic(err)
 	}
+	return m
+}
+
+/**
+* Loads keys from JSON key file
+**/
+func NewMCryptFromFile(pathToJSONKey string) (*MCrypt, error) {
+	return NewMCryptFromFileWithPassphrase(pathToJSONKey, "")
+}
+
+/**
+* Loads keys from JSON key file generated with GenerateRandomKeysWithPassphrase
+**/
+func NewMCryptFromFileWithPassphrase(pathToJSONKey, passphrase string) (*MCrypt, error) {
+	return NewMCryptFromProvider(&FileKeyProvider{Path: pathToJSONKey, Passphrase: passphrase})
+}
+
+/**
+* Loads keys from JSON key config read from r (e.g. secret mounted in a container)
+**/
+func NewMCryptFromReader(r io.Reader) (*MCrypt, error) {
+	cfg, err := loadKeyConfigFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewMCryptFromConfig(cfg)
+}
+
+/**
+* Loads keys from raw JSON key config
+**/
+func NewMCryptFromJSON(configJSON []byte) (*MCrypt, error) {
+	cfg, err := parseKeyConfig(configJSON)
+	if err != nil {
+		return nil, err
+	}
+	return NewMCryptFromConfig(cfg)
+}
+
+/**
+* Loads keys from environment variables. Either the whole JSON config in MCRYPT_KEY_CONFIG
+* or each field in MCRYPT_DOMAIN, MCRYPT_PUB, MCRYPT_PRIV, MCRYPT_PUBC, MCRYPT_PRIVC and MCRYPT_SECRET_KEY.
+* Encrypted configs are decrypted with MCRYPT_PASSPHRASE
+**/
+func NewMCryptFromEnv() (*MCrypt, error) {
+	return NewMCryptFromProvider(&EnvKeyProvider{})
+}
+
+/**
+* Creates MCrypt from in memory key config. Encrypted configs must be decrypted first (KeyConfig.Decrypt)
+**/
+func NewMCryptFromConfig(cfg *KeyConfig) (*MCrypt, error) {
+	if cfg == nil {
+		return nil, errors.New("key config is nil")
+	}
 
 	m := &MCrypt{
-		keyConfig: cfg,
+		keyConfig:  cfg,
+		sharedKeys: newSharedKeyCache(DefaultSharedKeyCacheSize),
 	}
 
-	err = m.applyConfigKeys(cfg)
+	err := m.applyConfigKeys(cfg)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return m
+	return m, nil
 }
 
 /**
 * Generates a new file with random encryption keys
 **/
 func GenerateRandomKeys(domain string, outputfilepath string) (*MCrypt, error) {
-	_, err := newKeyConfig(domain, outputfilepath)
+	_, err := newKeyConfig(domain, outputfilepath, "", false)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewMCrypt(outputfilepath), err
+	return NewMCryptFromFile(outputfilepath)
+}
+
+/**
+* Generates a new file with random encryption keys. Private keys in the file are encrypted
+* with a key derived from passphrase (argon2id)
+**/
+func GenerateRandomKeysWithPassphrase(domain, outputfilepath, passphrase string) (*MCrypt, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase must not be empty")
+	}
+	_, err := newKeyConfig(domain, outputfilepath, passphrase, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMCryptFromFileWithPassphrase(outputfilepath, passphrase)
 }
 
 func (m *MCrypt) applyConfigKeys(config *KeyConfig) error {
-	//(crypto.PrivKey, crypto.PubKey, *crypto.Curve25519PrivateKey, *crypto.Curve25519PublicKey, error)
-	err := config.validateKeyConf()
+	err := config.expandSeed()
+	if err != nil {
+		return err
+	}
+	err = config.Validate()
 	if err != nil {
 		return err
 	}
 
-	privSignKey, err := crypto.ConfigDecodeKey(config.Priv)
-	pubSignKey, err := crypto.ConfigDecodeKey(config.Pub)
-	privEncKey, err := crypto.ConfigDecodeEncryptKey(config.PrivC)
-	pubEncKey, err := crypto.ConfigDecodeEncryptKey(config.PubC)
-
-	signPrivKey, err := crypto.UnmarshalEd25519PrivateKey(privSignKey)
-	signPubKey, err := crypto.UnmarshalPublicKey(pubSignKey)
+	signPrivKey, signPubKey, err := decodeSignKeys(config.Pub, config.Priv)
+	if err != nil {
+		return err
+	}
+	encKeyPriv, encKeyPub, err := decodeEncKeys(config.PubC, config.PrivC)
 	if err != nil {
 		return err
 	}
-	encKeyPriv := &crypto.Curve25519PrivateKey{Key: privEncKey}
-	encKeyPub := &crypto.Curve25519PublicKey{Key: pubEncKey}
 
 	m.SignPrivKey = signPrivKey
 	m.SignPubKey = signPubKey
 	m.EncPrivKey = encKeyPriv
 	m.EncPubKey = encKeyPub
+	m.sharedKeys.purge()
+
+	// secret key is optional, key files created before it was generated don't have it
+	m.secretKey = nil
+	if config.SecretKey != "" {
+		secretKey, err := crypto.ConfigDecodeAesKey(config.SecretKey)
+		if err != nil {
+			return err
+		}
+		if len(secretKey) != 32 {
+			return errors.New("Secret key must be 32 bytes long")
+		}
+		m.secretKey = secretKey
+	}
 
-	return nil
+	return m.applyKeyring(config)
 }
 
 /**
 * ! this method should not be used server side. It's mainly to validate VerifyHandshake and for completeness sake
 * The handshakes are always created client side (check mobile SDK or Javascript SDK)
 * Creates base64 encoded signature of the contract content
+* Version 1 handshakes are plain ed25519 signatures (what the mobile and Javascript SDKs create), they stay
+* unchanged for compatibility. Use CreateHandshakeV2 for signatures bound to crypto.SignContextHandshake
 **/
 func (mc *MCrypt) CreateHandshake(handshakePrivateKey, handshakeContract string) (*string, error) {
-	privSignKey, err := crypto.ConfigDecodeKey(handshakePrivateKey)
+	signPrivKey, err := decodeHandshakePrivateKey(handshakePrivateKey)
 	if err != nil {
 		return nil, err
 	}
-	signPrivKey, err := crypto.UnmarshalEd25519PrivateKey(privSignKey)
+
+	signature, err := signPrivKey.Sign([]byte(handshakeContract))
 	if err != nil {
 		return nil, err
 	}
 
-	signature, err := signPrivKey.Sign([]byte(handshakeContract))
+	sign := base64.StdEncoding.EncodeToString(signature)
+	return &sign, nil
+}
+
+/**
+* Same as CreateHandshake, but the signature is Ed25519ctx bound to crypto.SignContextHandshake, so it
+* can't be replayed as a signature of the same bytes in another protocol. Verify with VerifyMailioHandshakeV2
+**/
+func (mc *MCrypt) CreateHandshakeV2(handshakePrivateKey, handshakeContract string) (*string, error) {
+	signPrivKey, err := decodeHandshakePrivateKey(handshakePrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := signPrivKey.SignWithContext([]byte(handshakeContract), crypto.SignContextHandshake)
 	if err != nil {
 		return nil, err
 	}
@@ -102,29 +213,63 @@ func (mc *MCrypt) CreateHandshake(handshakePrivateKey, handshakeContract string)
 * - Id reference to a unique public/private contract
 **/
 func (mc *MCrypt) VerifyMailioHandshake(handshakeOwnersPublicKey, handshakeSignature, handshakeContract string) (bool, error) {
+	signPubKey, sign, err := decodeHandshakeSignature(handshakeOwnersPublicKey, handshakeSignature)
+	if err != nil {
+		return false, err
+	}
 
-	pubKey, err := crypto.ConfigDecodeKey(handshakeOwnersPublicKey)
+	return signPubKey.Verify([]byte(handshakeContract), sign)
+}
+
+/**
+* Validates handshake created with CreateHandshakeV2 (Ed25519ctx with crypto.SignContextHandshake).
+* Version 1 handshakes don't verify as version 2 and vice versa
+**/
+func (mc *MCrypt) VerifyMailioHandshakeV2(handshakeOwnersPublicKey, handshakeSignature, handshakeContract string) (bool, error) {
+	signPubKey, sign, err := decodeHandshakeSignature(handshakeOwnersPublicKey, handshakeSignature)
 	if err != nil {
 		return false, err
 	}
 
+	return signPubKey.VerifyWithContext([]byte(handshakeContract), sign, crypto.SignContextHandshake)
+}
+
+// decodeHandshakePrivateKey decodes base64 ed25519 private key of the handshake owner
+func decodeHandshakePrivateKey(handshakePrivateKey string) (*crypto.Ed25519PrivateKey, error) {
+	privSignKey, err := crypto.ConfigDecodeKey(handshakePrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	signPrivKey, err := crypto.UnmarshalEd25519PrivateKey(privSignKey)
+	if err != nil {
+		return nil, err
+	}
+	return signPrivKey.(*crypto.Ed25519PrivateKey), nil
+}
+
+// decodeHandshakeSignature decodes base64 ed25519 public key of the handshake owner and the signature
+func decodeHandshakeSignature(handshakeOwnersPublicKey, handshakeSignature string) (*crypto.Ed25519PublicKey, []byte, error) {
+	pubKey, err := crypto.ConfigDecodeKey(handshakeOwnersPublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if len(pubKey) != ed25519.PublicKeySize {
-		return false, errors.New("invalid size of public key")
+		return nil, nil, errors.New("invalid size of public key")
 	}
 
 	sign, err := base64.StdEncoding.DecodeString(handshakeSignature)
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
 
 	if len(sign) != ed25519.SignatureSize {
-		return false, errors.New("invalid signature size")
+		return nil, nil, errors.New("invalid signature size")
 	}
 
 	signPubKey, err := crypto.UnmarshalEd25519PublicKey(pubKey)
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
-
-	return signPubKey.Verify([]byte(handshakeContract), sign)
+	return signPubKey.(*crypto.Ed25519PublicKey), sign, nil
 }
@@ -177,3 +177,43 @@ func TestHandshakeVerify(t *testing.T) {
 	assert.Equal(t, true, isValid)
 
 }
+
+func TestHandshakeV2Verify(t *testing.T) {
+	handshakeContract := "user@test.io"
+
+	defer cleanupfiles("test-domain-v2.json")
+	mcrypt, err := GenerateRandomKeys("test.io", "test-domain-v2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	privKey, err := mcrypt.SignPrivKey.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, err := mcrypt.SignPubKey.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := base64.StdEncoding.EncodeToString(privKey)
+	publicKey := base64.StdEncoding.EncodeToString(pubKey)
+
+	signature, err := mcrypt.CreateHandshakeV2(privateKey, handshakeContract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isValid, err := mcrypt.VerifyMailioHandshakeV2(publicKey, *signature, handshakeContract)
+	assert.NoError(t, err)
+	assert.True(t, isValid)
+
+	// signatures of one handshake version don't verify as the other
+	isValid, err = mcrypt.VerifyMailioHandshake(publicKey, *signature, handshakeContract)
+	assert.NoError(t, err)
+	assert.False(t, isValid)
+	legacy, err := mcrypt.CreateHandshake(privateKey, handshakeContract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isValid, err = mcrypt.VerifyMailioHandshakeV2(publicKey, *legacy, handshakeContract)
+	assert.NoError(t, err)
+	assert.False(t, isValid)
+}
@@ -0,0 +1,109 @@
+package mcrypt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// KeyConfigVersion is the key config schema version written by this library.
+// Files without a version field are version 0 (the original layout)
+const KeyConfigVersion = 5
+
+// keyConfigMigration upgrades raw JSON key config from version N to N+1
+type keyConfigMigration func(raw map[string]json.RawMessage) error
+
+// keyConfigMigrations[N] migrates version N to N+1. Append a migration when bumping KeyConfigVersion
+var keyConfigMigrations = []keyConfigMigration{
+	// 0 -> 1: only adds the version field
+	func(raw map[string]json.RawMessage) error {
+		return nil
+	},
+	// 1 -> 2: adds created and keyring (both optional), version bump keeps older readers from ignoring retired keys
+	func(raw map[string]json.RawMessage) error {
+		return nil
+	},
+	// 2 -> 3: checksum becomes required, migrated configs get one when saved (encrypted configs only
+	// with the passphrase, see MigrateKeyConfigFileWithPassphrase)
+	func(raw map[string]json.RawMessage) error {
+		return nil
+	},
+	// 3 -> 4: adds single seed mode (priv, privC and secretKey derived from seed and not stored), version
+	// bump makes older readers reject seed only files instead of loading a config with empty private keys
+	func(raw map[string]json.RawMessage) error {
+		return nil
+	},
+	// 4 -> 5: adds deriveRoot (optional), older readers would derive subkeys from the active key instead
+	func(raw map[string]json.RawMessage) error {
+		return nil
+	},
+}
+
+// migrateKeyConfig upgrades raw JSON key config to KeyConfigVersion. Returns true if anything was migrated
+func migrateKeyConfig(raw map[string]json.RawMessage) (bool, error) {
+	version := 0
+	if v, ok := raw["version"]; ok {
+		err := json.Unmarshal(v, &version)
+		if err != nil {
+			return false, fmt.Errorf("invalid key config version: %w", err)
+		}
+	}
+	if version > KeyConfigVersion {
+		return false, fmt.Errorf("%w: file version %d, supported up to %d", ErrUnsupportedKeyConfigVersion, version, KeyConfigVersion)
+	}
+	if version < 0 {
+		return false, fmt.Errorf("invalid key config version: %d", version)
+	}
+	migrated := false
+	for ; version < KeyConfigVersion; version++ {
+		err := keyConfigMigrations[version](raw)
+		if err != nil {
+			return false, fmt.Errorf("key config migration from version %d failed: %w", version, err)
+		}
+		v, err := json.Marshal(version + 1)
+		if err != nil {
+			return false, err
+		}
+		raw["version"] = v
+		migrated = true
+	}
+	return migrated, nil
+}
+
+// MigrateKeyConfigFile upgrades key file to the current schema version and rewrites it.
+// Returns false if the file was already up to date. Encrypted key files without a checksum
+// need the passphrase, use MigrateKeyConfigFileWithPassphrase
+func MigrateKeyConfigFile(filePath string) (bool, error) {
+	return MigrateKeyConfigFileWithPassphrase(filePath, "")
+}
+
+// MigrateKeyConfigFileWithPassphrase is MigrateKeyConfigFile for encrypted key files. Files encrypted before the
+// checksum was required are decrypted, get the checksum and are encrypted again with passphrase (without it
+// ErrPassphraseRequired is returned and the file is left untouched, rewriting it would make it unloadable)
+func MigrateKeyConfigFileWithPassphrase(filePath, passphrase string) (bool, error) {
+	conf, err := loadKeyConfigFromFile(filePath)
+	if err != nil {
+		return false, err
+	}
+	if !conf.migrated {
+		return false, nil
+	}
+	if conf.Encrypted != nil && conf.Checksum == "" {
+		if passphrase == "" {
+			return false, ErrPassphraseRequired
+		}
+		decrypted := conf.clone()
+		err = decrypted.Decrypt(passphrase)
+		if err != nil {
+			return false, err
+		}
+		conf, err = decrypted.encrypt(passphrase)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = conf.save(filePath)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,152 @@
+package mcrypt
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestMigrateLegacyKeyConfigFile(t *testing.T) {
+	defer cleanupfiles("test-legacy.json")
+
+	generated, err := GenerateRandomKeys("test.io", "test-legacy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// write the original (version 0) layout without the version field
+	legacy := map[string]string{
+		"pub":       generated.keyConfig.Pub,
+		"priv":      generated.keyConfig.Priv,
+		"pubC":      generated.keyConfig.PubC,
+		"privC":     generated.keyConfig.PrivC,
+		"domain":    generated.keyConfig.Domain,
+		"secretKey": "",
+	}
+	legacyJSON, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile("test-legacy.json", legacyJSON, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadKeyConfigFromFile("test-legacy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, KeyConfigVersion, cfg.Version)
+	assert.True(t, cfg.migrated)
+
+	migrated, err := MigrateKeyConfigFile("test-legacy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, migrated)
+
+	migrated, err = MigrateKeyConfigFile("test-legacy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, migrated)
+
+	m, err := NewMCryptFromFile("test-legacy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, m.SignPrivKey.Equals(generated.SignPrivKey))
+}
+
+func TestMigrateEncryptedKeyConfigWithoutChecksum(t *testing.T) {
+	defer cleanupfiles("test-legacy-encrypted.json")
+
+	generated, err := GenerateRandomKeysWithPassphrase("test.io", "test-legacy-encrypted.json", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// encrypted file from before the version and checksum fields
+	content, err := ioutil.ReadFile("test-legacy-encrypted.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(content, &raw))
+	delete(raw, "version")
+	delete(raw, "checksum")
+	legacyJSON, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile("test-legacy-encrypted.json", legacyJSON, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without the passphrase the file is left as it was
+	_, err = MigrateKeyConfigFile("test-legacy-encrypted.json")
+	assert.Equal(t, ErrPassphraseRequired, err)
+	unchanged, err := ioutil.ReadFile("test-legacy-encrypted.json")
+	assert.NoError(t, err)
+	assert.Equal(t, legacyJSON, unchanged)
+
+	_, err = MigrateKeyConfigFileWithPassphrase("test-legacy-encrypted.json", "wrong")
+	assert.Equal(t, ErrWrongPassphrase, err)
+
+	migrated, err := MigrateKeyConfigFileWithPassphrase("test-legacy-encrypted.json", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, migrated)
+
+	content, err = ioutil.ReadFile("test-legacy-encrypted.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw = nil
+	assert.NoError(t, json.Unmarshal(content, &raw))
+	assert.Equal(t, float64(KeyConfigVersion), raw["version"])
+	assert.NotEmpty(t, raw["checksum"])
+	assert.NotNil(t, raw["encrypted"])
+	assert.Empty(t, raw["priv"])
+
+	m, err := NewMCryptFromFileWithPassphrase("test-legacy-encrypted.json", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, m.SignPrivKey.Equals(generated.SignPrivKey))
+}
+
+func TestKeyConfigFromNewerVersion(t *testing.T) {
+	_, err := parseKeyConfig([]byte(`{"version":1000,"domain":"test.io"}`))
+	assert.True(t, errors.Is(err, ErrUnsupportedKeyConfigVersion))
+}
+
+func TestSeedKeyConfigVersion(t *testing.T) {
+	defer cleanupfiles("test-seed-version.json")
+
+	generated, err := GenerateRandomSeedKeys("test.io", "test-seed-version.json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile("test-seed-version.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(content, &raw))
+	assert.Equal(t, float64(KeyConfigVersion), raw["version"])
+
+	// seed only files written before the version bump still load
+	raw["version"] = 3
+	older, err := json.Marshal(raw)
+	assert.NoError(t, err)
+	cfg, err := parseKeyConfig(older)
+	assert.NoError(t, err)
+	assert.True(t, cfg.migrated)
+	m, err := NewMCryptFromConfig(cfg)
+	assert.NoError(t, err)
+	assert.True(t, m.SignPrivKey.Equals(generated.SignPrivKey))
+}
@@ -0,0 +1,116 @@
+package mcrypt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/igorrendulic/mcrypt-sdk-go/utils"
+)
+
+// configFromMnemonic returns single seed key config of the 24 word mnemonic. Without passphrase the mnemonic
+// encodes the seed itself. With passphrase the seed is the first half of the BIP-39 seed and the mnemonic
+// entropy is kept in the config so ExportMnemonic returns the same phrase in both modes
+func configFromMnemonic(domain, mnemonic, passphrase string) (*KeyConfig, error) {
+	entropy, err := crypto.EntropyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	if len(entropy) != 32 {
+		return nil, fmt.Errorf("%w: 24 words required", crypto.ErrInvalidMnemonic)
+	}
+	cfg := &KeyConfig{
+		Version: KeyConfigVersion,
+		Domain:  domain,
+		Seed:    crypto.ConfigEncodeKey(entropy),
+	}
+	if passphrase != "" {
+		seed, err := crypto.MnemonicToSeed(mnemonic, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Seed = crypto.ConfigEncodeKey(seed[:32])
+		cfg.MnemonicEntropy = crypto.ConfigEncodeKey(entropy)
+	}
+	return cfg, nil
+}
+
+/**
+* Creates MCrypt with all keys derived from mnemonic phrase (and optional mnemonic passphrase)
+**/
+func NewMCryptFromMnemonic(domain, mnemonic, passphrase string) (*MCrypt, error) {
+	cfg, err := configFromMnemonic(domain, mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewMCryptFromConfig(cfg)
+}
+
+/**
+* Generates a new single seed key file and returns the 24 word mnemonic backup of it.
+* The mnemonic alone restores all keys (RestoreKeysFromMnemonic)
+**/
+func GenerateMnemonicKeys(domain, outputfilepath string) (*MCrypt, string, error) {
+	mnemonic, err := crypto.NewMnemonic()
+	if err != nil {
+		return nil, "", err
+	}
+	m, err := RestoreKeysFromMnemonic(domain, mnemonic, "", outputfilepath)
+	if err != nil {
+		return nil, "", err
+	}
+	return m, mnemonic, nil
+}
+
+/**
+* Restores key file from mnemonic phrase (and optional mnemonic passphrase). Existing files are not overwritten
+**/
+func RestoreKeysFromMnemonic(domain, mnemonic, passphrase, outputfilepath string) (*MCrypt, error) {
+	exists, err := utils.Exists(outputfilepath)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.New("File already exists! If you override it you might loose the keys")
+	}
+	m, err := NewMCryptFromMnemonic(domain, mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	m.keyConfig.Created = &now
+	err = m.SaveKeyConfig(outputfilepath, "")
+	if err != nil {
+		return nil, err
+	}
+	m.keyConfig.filePath = outputfilepath
+	return m, nil
+}
+
+// ExportMnemonic returns 24 word mnemonic of a single seed key config. Restoring it gives the same keys, with the
+// same mnemonic passphrase if keys were restored with one (see MnemonicRequiresPassphrase). Keyring (retired keys)
+// is not part of the mnemonic
+func (m *MCrypt) ExportMnemonic() (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.keyConfig.Seed == "" {
+		return "", ErrNoSeed
+	}
+	encoded := m.keyConfig.Seed
+	if m.keyConfig.MnemonicEntropy != "" {
+		encoded = m.keyConfig.MnemonicEntropy
+	}
+	entropy, err := crypto.ConfigDecodeKey(encoded)
+	if err != nil {
+		return "", err
+	}
+	return crypto.MnemonicFromEntropy(entropy)
+}
+
+// MnemonicRequiresPassphrase reports whether the exported mnemonic has to be restored with a mnemonic passphrase
+func (m *MCrypt) MnemonicRequiresPassphrase() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keyConfig.MnemonicEntropy != ""
+}
@@ -0,0 +1,112 @@
+package mcrypt
+
+import (
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func TestMnemonicBackupAndRestore(t *testing.T) {
+	defer cleanupfiles("test-mnemonic.json", "test-mnemonic-restored.json")
+
+	generated, mnemonic, err := GenerateMnemonicKeys("test.io", "test-mnemonic.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := generated.ExportMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, mnemonic, exported)
+
+	restored, err := RestoreKeysFromMnemonic("test.io", mnemonic, "", "test-mnemonic-restored.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewMCryptFromFile("test-mnemonic-restored.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []*MCrypt{restored, loaded} {
+		assert.True(t, m.SignPrivKey.Equals(generated.SignPrivKey))
+		assert.Equal(t, generated.EncPrivKey.Raw(), m.EncPrivKey.Raw())
+		assert.Equal(t, generated.secretKey, m.secretKey)
+	}
+
+	_, err = RestoreKeysFromMnemonic("test.io", mnemonic, "", "test-mnemonic-restored.json")
+	assert.Error(t, err)
+}
+
+func TestMnemonicWithPassphrase(t *testing.T) {
+	mnemonic, err := crypto.NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m1, err := NewMCryptFromMnemonic("test.io", mnemonic, "extra words")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := NewMCryptFromMnemonic("test.io", mnemonic, "extra words")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutPassphrase, err := NewMCryptFromMnemonic("test.io", mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, m1.SignPrivKey.Equals(m2.SignPrivKey))
+	assert.False(t, m1.SignPrivKey.Equals(withoutPassphrase.SignPrivKey))
+
+	_, err = NewMCryptFromMnemonic("test.io", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	assert.Error(t, err)
+}
+
+func TestMnemonicWithPassphraseRoundTrip(t *testing.T) {
+	defer cleanupfiles("test-mnemonic-pass.json", "test-mnemonic-pass-restored.json")
+
+	mnemonic, err := crypto.NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := RestoreKeysFromMnemonic("test.io", mnemonic, "extra words", "test-mnemonic-pass.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, restored.MnemonicRequiresPassphrase())
+	loaded, err := NewMCryptFromFile("test-mnemonic-pass.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := loaded.ExportMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, mnemonic, exported)
+
+	again, err := RestoreKeysFromMnemonic("test.io", exported, "extra words", "test-mnemonic-pass-restored.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, again.SignPrivKey.Equals(restored.SignPrivKey))
+	assert.Equal(t, restored.EncPrivKey.Raw(), again.EncPrivKey.Raw())
+	assert.Equal(t, restored.secretKey, again.secretKey)
+
+	withoutPassphrase, err := NewMCryptFromMnemonic("test.io", exported, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, withoutPassphrase.MnemonicRequiresPassphrase())
+	assert.False(t, withoutPassphrase.SignPrivKey.Equals(restored.SignPrivKey))
+}
+
+func TestExportMnemonicRequiresSeed(t *testing.T) {
+	defer cleanupfiles("test-no-seed.json")
+
+	m, err := GenerateRandomKeys("test.io", "test-no-seed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.ExportMnemonic()
+	assert.Equal(t, ErrNoSeed, err)
+}
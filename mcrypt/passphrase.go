@@ -0,0 +1,151 @@
+package mcrypt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+)
+
+const kdfArgon2id = "argon2id"
+
+// argon2idParams used for new encrypted key configs (package variable so tests can lower the cost)
+var argon2idParams = crypto.DefaultArgon2idParams
+
+// MaxArgon2idParams caps the cost parameters read from key files (4x DefaultArgon2idParams) so a crafted
+// file can't exhaust memory or CPU while loading
+var MaxArgon2idParams = crypto.Argon2idParams{
+	Time:    4 * crypto.DefaultArgon2idParams.Time,
+	Memory:  4 * crypto.DefaultArgon2idParams.Memory,
+	Threads: 4 * crypto.DefaultArgon2idParams.Threads,
+}
+
+// encrypt returns a copy of the config with priv, privC and secretKey sealed by a passphrase derived key
+func (conf *KeyConfig) encrypt(passphrase string) (*KeyConfig, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase must not be empty")
+	}
+	checksum, err := conf.checksum()
+	if err != nil {
+		return nil, err
+	}
+	salt, err := crypto.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.DeriveArgon2idKey([]byte(passphrase), salt, argon2idParams)
+	if err != nil {
+		return nil, err
+	}
+	secrets := &secretKeys{
+		Priv:      conf.Priv,
+		PrivC:     conf.PrivC,
+		SecretKey: conf.SecretKey,
+	}
+	if conf.Seed != "" {
+		// derived from the seed on load
+		secrets = &secretKeys{Seed: conf.Seed, MnemonicEntropy: conf.MnemonicEntropy}
+	}
+	var keyring *Keyring
+	if conf.Keyring != nil {
+		// copy the keyring without private keys, those go into the sealed secrets
+		secrets.Keyring = make(map[string]string)
+		keyring = &Keyring{
+			Sign: append([]KeyringEntry(nil), conf.Keyring.Sign...),
+			Enc:  append([]KeyringEntry(nil), conf.Keyring.Enc...),
+		}
+		for _, entries := range [][]KeyringEntry{keyring.Sign, keyring.Enc} {
+			for i := range entries {
+				secrets.Keyring[entries[i].ID] = entries[i].Priv
+				entries[i].Priv = ""
+			}
+		}
+	}
+	secretsJSON, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := crypto.SecretboxSeal(key, secretsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := *conf
+	encrypted.Priv = ""
+	encrypted.PrivC = ""
+	encrypted.SecretKey = ""
+	encrypted.Seed = ""
+	encrypted.MnemonicEntropy = ""
+	encrypted.Keyring = keyring
+	encrypted.Checksum = checksum
+	encrypted.Encrypted = &EncryptedKeys{
+		KDF:        kdfArgon2id,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Time:       argon2idParams.Time,
+		Memory:     argon2idParams.Memory,
+		Threads:    argon2idParams.Threads,
+		Ciphertext: base64.StdEncoding.EncodeToString(sealed),
+	}
+	return &encrypted, nil
+}
+
+// Decrypt opens the passphrase sealed secret fields in place. Configs that aren't encrypted are left untouched
+func (conf *KeyConfig) Decrypt(passphrase string) error {
+	enc := conf.Encrypted
+	if enc == nil {
+		return nil
+	}
+	if passphrase == "" {
+		return ErrPassphraseRequired
+	}
+	if enc.KDF != kdfArgon2id {
+		return fmt.Errorf("unsupported key derivation function: %s", enc.KDF)
+	}
+	if enc.Time > MaxArgon2idParams.Time || enc.Memory > MaxArgon2idParams.Memory || enc.Threads > MaxArgon2idParams.Threads {
+		return ErrKDFParamsTooHigh
+	}
+	salt, err := base64.StdEncoding.DecodeString(enc.Salt)
+	if err != nil {
+		return err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(enc.Ciphertext)
+	if err != nil {
+		return err
+	}
+	key, err := crypto.DeriveArgon2idKey([]byte(passphrase), salt, crypto.Argon2idParams{
+		Time:    enc.Time,
+		Memory:  enc.Memory,
+		Threads: enc.Threads,
+	})
+	if err != nil {
+		return err
+	}
+	plain, err := crypto.SecretboxOpen(key, sealed)
+	if err != nil {
+		return ErrWrongPassphrase
+	}
+	var secrets secretKeys
+	err = json.Unmarshal(plain, &secrets)
+	if err != nil {
+		return err
+	}
+	conf.Priv = secrets.Priv
+	conf.PrivC = secrets.PrivC
+	conf.SecretKey = secrets.SecretKey
+	conf.Seed = secrets.Seed
+	conf.MnemonicEntropy = secrets.MnemonicEntropy
+	if conf.Keyring != nil {
+		for _, entries := range [][]KeyringEntry{conf.Keyring.Sign, conf.Keyring.Enc} {
+			for i := range entries {
+				entries[i].Priv = secrets.Keyring[entries[i].ID]
+			}
+		}
+	}
+	err = conf.expandSeed()
+	if err != nil {
+		return err
+	}
+	return conf.verifyChecksum()
+}
@@ -0,0 +1,67 @@
+package mcrypt
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func init() {
+	// keep tests fast
+	argon2idParams = crypto.Argon2idParams{Time: 1, Memory: 1024, Threads: 1}
+}
+
+func TestGenerateKeysWithPassphrase(t *testing.T) {
+	defer cleanupfiles("test-pass.json")
+
+	generated, err := GenerateRandomKeysWithPassphrase("test.io", "test-pass.json", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile("test-pass.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	privBytes, err := generated.SignPrivKey.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, strings.Contains(string(content), crypto.ConfigEncodeKey(privBytes)))
+	assert.False(t, strings.Contains(string(content), crypto.ConfigEncodeEncryptKey(generated.EncPrivKey.Raw())))
+
+	mcrypt := NewMCryptWithPassphrase("test-pass.json", "secret passphrase")
+	assert.True(t, mcrypt.SignPrivKey.Equals(generated.SignPrivKey))
+	assert.Equal(t, generated.EncPrivKey.Raw(), mcrypt.EncPrivKey.Raw())
+}
+
+func TestLoadEncryptedKeysFailures(t *testing.T) {
+	defer cleanupfiles("test-pass.json")
+
+	_, err := GenerateRandomKeysWithPassphrase("test.io", "test-pass.json", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadKeyConfigFromFile("test-pass.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ErrPassphraseRequired, cfg.Validate())
+	assert.Equal(t, ErrPassphraseRequired, cfg.Decrypt(""))
+	assert.Equal(t, ErrWrongPassphrase, cfg.Decrypt("wrong passphrase"))
+
+	params := *cfg.Encrypted
+	cfg.Encrypted.Memory = 1 << 31
+	assert.Equal(t, ErrKDFParamsTooHigh, cfg.Decrypt("secret passphrase"))
+	cfg.Encrypted.Memory = params.Memory
+	cfg.Encrypted.Time = MaxArgon2idParams.Time + 1
+	assert.Equal(t, ErrKDFParamsTooHigh, cfg.Decrypt("secret passphrase"))
+	cfg.Encrypted.Time = params.Time
+
+	assert.NoError(t, cfg.Decrypt("secret passphrase"))
+	assert.NoError(t, cfg.Validate())
+}
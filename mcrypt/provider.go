@@ -0,0 +1,122 @@
+package mcrypt
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// KeyProvider supplies the domain key config (signing, encryption and secret keys and the keyring) to MCrypt.
+//
+// Contract for implementations:
+//   - LoadKeyConfig returns a decrypted config (Priv and PrivC set). Providers fetching JSON key configs decode
+//     them with ParseKeyConfig (migration, decryption, checksum and validation)
+//   - every call returns a new KeyConfig, MCrypt keeps and modifies the returned value (e.g. RotateKeys)
+//   - LoadKeyConfig may be called more than once (key reloads) and from multiple goroutines
+//   - errors are returned as is to the caller of NewMCryptFromProvider, secrets must not be part of error messages
+//
+// Keys are validated by MCrypt, providers only fetch and decode them
+type KeyProvider interface {
+	LoadKeyConfig() (*KeyConfig, error)
+}
+
+// FileKeyProvider loads keys from a JSON key file. Passphrase is required for encrypted key files
+type FileKeyProvider struct {
+	Path       string
+	Passphrase string
+}
+
+// LoadKeyConfig reads and decrypts the key file
+func (p *FileKeyProvider) LoadKeyConfig() (*KeyConfig, error) {
+	dat, err := readKeyFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ParseKeyConfig(dat, p.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	cfg.filePath = p.Path
+	return cfg, nil
+}
+
+// EnvKeyProvider loads keys from environment variables (see NewMCryptFromEnv)
+type EnvKeyProvider struct{}
+
+// LoadKeyConfig reads the key config from environment and decrypts it with MCRYPT_PASSPHRASE
+func (p *EnvKeyProvider) LoadKeyConfig() (*KeyConfig, error) {
+	if dat, ok := os.LookupEnv(EnvKeyConfig); ok {
+		return ParseKeyConfig([]byte(dat), os.Getenv(EnvPassphrase))
+	}
+	cfg, err := loadKeyConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// MemoryKeyProvider holds a decrypted key config in memory (tests, keys fetched by the application itself)
+type MemoryKeyProvider struct {
+	mu     sync.RWMutex
+	config *KeyConfig
+}
+
+// NewMemoryKeyProvider creates in-memory provider with a copy of cfg
+func NewMemoryKeyProvider(cfg *KeyConfig) *MemoryKeyProvider {
+	p := &MemoryKeyProvider{}
+	p.SetKeyConfig(cfg)
+	return p
+}
+
+// NewMemoryKeyProviderFromJSON creates in-memory provider with JSON key config decoded by ParseKeyConfig
+func NewMemoryKeyProviderFromJSON(configJSON []byte, passphrase string) (*MemoryKeyProvider, error) {
+	cfg, err := ParseKeyConfig(configJSON, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewMemoryKeyProvider(cfg), nil
+}
+
+// SetKeyConfig replaces the key config returned by the provider
+func (p *MemoryKeyProvider) SetKeyConfig(cfg *KeyConfig) {
+	var c *KeyConfig
+	if cfg != nil {
+		c = cfg.clone()
+	}
+	p.mu.Lock()
+	p.config = c
+	p.mu.Unlock()
+}
+
+// LoadKeyConfig returns a copy of the key config
+func (p *MemoryKeyProvider) LoadKeyConfig() (*KeyConfig, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.config == nil {
+		return nil, errors.New("key config is nil")
+	}
+	return p.config.clone(), nil
+}
+
+/**
+* Creates MCrypt with keys supplied by the provider (file, environment, in-memory or custom secret manager)
+**/
+func NewMCryptFromProvider(p KeyProvider) (*MCrypt, error) {
+	if p == nil {
+		return nil, errors.New("key provider is nil")
+	}
+	cfg, err := p.LoadKeyConfig()
+	if err != nil {
+		return nil, err
+	}
+	m, err := NewMCryptFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.provider = p
+	return m, nil
+}
@@ -0,0 +1,129 @@
+package mcrypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+// secretManagerProvider is a custom provider fetching JSON key config from an external store
+type secretManagerProvider struct {
+	secrets map[string][]byte
+	name    string
+}
+
+func (p *secretManagerProvider) LoadKeyConfig() (*KeyConfig, error) {
+	dat, ok := p.secrets[p.name]
+	if !ok {
+		return nil, errors.New("secret not found")
+	}
+	return ParseKeyConfig(dat, "")
+}
+
+func TestKeyProviders(t *testing.T) {
+	defer cleanupfiles("test-provider.json")
+
+	generated, err := GenerateRandomKeys("test.io", "test-provider.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromFile, err := NewMCryptFromProvider(&FileKeyProvider{Path: "test-provider.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, fromFile.SignPrivKey.Equals(generated.SignPrivKey))
+
+	memory := NewMemoryKeyProvider(generated.keyConfig)
+	fromMemory, err := NewMCryptFromProvider(memory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, fromMemory.SignPrivKey.Equals(generated.SignPrivKey))
+
+	// provider returns copies, rotating keys doesn't change the provider config
+	err = fromMemory.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := NewMCryptFromProvider(memory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, again.SignPrivKey.Equals(generated.SignPrivKey))
+
+	configJSON, err := generated.keyConfig.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := &secretManagerProvider{secrets: map[string][]byte{"mcrypt": configJSON}, name: "mcrypt"}
+	fromCustom, err := NewMCryptFromProvider(custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, fromCustom.SignPrivKey.Equals(generated.SignPrivKey))
+
+	custom.name = "missing"
+	_, err = NewMCryptFromProvider(custom)
+	assert.EqualError(t, err, "secret not found")
+
+	_, err = NewMCryptFromProvider(NewMemoryKeyProvider(nil))
+	assert.Error(t, err)
+	_, err = NewMCryptFromProvider(nil)
+	assert.Error(t, err)
+}
+
+func TestFileKeyProviderPassphrase(t *testing.T) {
+	defer cleanupfiles("test-provider-enc.json")
+
+	_, err := GenerateRandomKeysWithPassphrase("test.io", "test-provider-enc.json", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewMCryptFromProvider(&FileKeyProvider{Path: "test-provider-enc.json"})
+	assert.Equal(t, ErrPassphraseRequired, err)
+
+	_, err = NewMCryptFromProvider(&FileKeyProvider{Path: "test-provider-enc.json", Passphrase: "secret passphrase"})
+	assert.NoError(t, err)
+}
+
+func TestParseKeyConfig(t *testing.T) {
+	defer cleanupfiles("test-parse.json")
+
+	generated, err := GenerateRandomKeysWithPassphrase("test.io", "test-parse.json", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := readKeyFile("test-parse.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseKeyConfig(content, "")
+	assert.Equal(t, ErrPassphraseRequired, err)
+	_, err = ParseKeyConfig(content, "wrong passphrase")
+	assert.Equal(t, ErrWrongPassphrase, err)
+
+	memory, err := NewMemoryKeyProviderFromJSON(content, "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromMemory, err := NewMCryptFromProvider(memory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, fromMemory.SignPrivKey.Equals(generated.SignPrivKey))
+
+	// invalid fields are reported by validation
+	cfg := generated.keyConfig.clone()
+	cfg.Encrypted = nil
+	cfg.PubC = cfg.Pub
+	invalid, err := cfg.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ParseKeyConfig(invalid, "")
+	var verr *ValidationError
+	assert.True(t, errors.As(err, &verr))
+}
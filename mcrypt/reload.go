@@ -0,0 +1,157 @@
+package mcrypt
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// VersionedKeyProvider is implemented by providers that can cheaply tell whether keys changed (e.g. file
+// modification time). WatchKeys loads keys only when KeyVersion changes, other providers are loaded on every poll
+type VersionedKeyProvider interface {
+	KeyProvider
+	KeyVersion() (string, error)
+}
+
+// KeyVersion returns modification time and size of the key file
+func (p *FileKeyProvider) KeyVersion() (string, error) {
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+// ActiveKeys returns the active signing and encryption keys as a consistent pair
+func (m *MCrypt) ActiveKeys() (*SignKey, *EncKey) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.signKeys[0], m.encKeys[0]
+}
+
+// ReloadKeys loads keys from the key provider again, validates them and swaps them atomically.
+// Calls in progress finish with the previous keys. On error the current keys are kept
+func (m *MCrypt) ReloadKeys() error {
+	fresh, _, err := m.loadFromProvider()
+	if err != nil {
+		return err
+	}
+	m.swapKeys(fresh)
+	return nil
+}
+
+// loadFromProvider loads and validates keys from the provider. Returns them with the config checksum
+func (m *MCrypt) loadFromProvider() (*MCrypt, string, error) {
+	if m.provider == nil {
+		return nil, "", ErrNoKeyProvider
+	}
+	cfg, err := m.provider.LoadKeyConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	fresh, err := NewMCryptFromConfig(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	checksum, err := fresh.keyConfig.checksum()
+	if err != nil {
+		return nil, "", err
+	}
+	return fresh, checksum, nil
+}
+
+func (m *MCrypt) swapKeys(fresh *MCrypt) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setKeys(fresh)
+}
+
+// setKeys replaces keys with the ones of fresh. Callers hold m.mu
+func (m *MCrypt) setKeys(fresh *MCrypt) {
+	m.keyConfig = fresh.keyConfig
+	m.signKeys = fresh.signKeys
+	m.encKeys = fresh.encKeys
+	m.secretKey = fresh.secretKey
+	m.sharedKeys.purge()
+}
+
+// WatchKeys polls the key provider every interval and swaps in changed keys (see ReloadKeys).
+// onReload (optional) is called with nil after keys were replaced and with the error when a changed key
+// source fails to load or validate (the same error is reported once). Call stop to end watching
+func (m *MCrypt) WatchKeys(interval time.Duration, onReload func(err error)) (stop func(), err error) {
+	if m.provider == nil {
+		return nil, ErrNoKeyProvider
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("watch interval must be positive")
+	}
+	versioned, _ := m.provider.(VersionedKeyProvider)
+	lastVersion := ""
+	if versioned != nil {
+		lastVersion, err = versioned.KeyVersion()
+		if err != nil {
+			return nil, err
+		}
+	}
+	// keys changed in memory (RotateKeys, RevokeKey) are kept until the key source changes
+	m.mu.RLock()
+	lastChecksum, err := m.keyConfig.checksum()
+	m.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		lastErr := ""
+		report := func(err error) {
+			if err != nil {
+				if err.Error() == lastErr {
+					return
+				}
+				lastErr = err.Error()
+			}
+			if onReload != nil {
+				onReload(err)
+			}
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if versioned != nil {
+				version, err := versioned.KeyVersion()
+				if err != nil {
+					report(err)
+					continue
+				}
+				if version == lastVersion {
+					continue
+				}
+				lastVersion = version
+			}
+			fresh, checksum, err := m.loadFromProvider()
+			if err != nil {
+				// file may still be written, try again on the next tick
+				lastVersion = ""
+				report(err)
+				continue
+			}
+			lastErr = ""
+			if checksum == lastChecksum {
+				continue
+			}
+			lastChecksum = checksum
+			m.swapKeys(fresh)
+			report(nil)
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
@@ -0,0 +1,201 @@
+package mcrypt
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func TestReloadKeysFromFile(t *testing.T) {
+	defer cleanupfiles("test-reload.json")
+
+	m, err := GenerateRandomKeys("test.io", "test-reload.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewMCryptFromFile("test-reload.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = other.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = other.SaveKeyConfig("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.ReloadKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signKey, encKey := m.ActiveKeys()
+	otherSign, otherEnc := other.ActiveKeys()
+	assert.True(t, signKey.PubKey.Equals(otherSign.PubKey))
+	assert.Equal(t, otherEnc.PubKey.Raw(), encKey.PubKey.Raw())
+	assert.Equal(t, 2, len(m.SignKeys()))
+
+	cfg, err := generateConfigKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Domain = "test.io"
+	fromConfig, err := NewMCryptFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ErrNoKeyProvider, fromConfig.ReloadKeys())
+}
+
+func TestWatchKeys(t *testing.T) {
+	seedA := []byte("0123456789abcdef0123456789abcdef")
+	seedB := []byte("fedcba9876543210fedcba9876543210")
+	a, err := NewMCryptFromSeed("test.io", seedA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewMCryptFromSeed("test.io", seedB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := NewMemoryKeyProvider(a.keyConfig)
+	m, err := NewMCryptFromProvider(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloads := make(chan error, 10)
+	stop, err := m.WatchKeys(5*time.Millisecond, func(err error) { reloads <- err })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	// sign and verify while keys are swapped, every call sees a consistent key set
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				sig, err := m.Sign([]byte("msg"))
+				assert.NoError(t, err)
+				signKey, encKey := m.ActiveKeys()
+				assert.NotNil(t, signKey)
+				assert.NotNil(t, encKey)
+				_, err = m.Verify([]byte("msg"), sig)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+
+	provider.SetKeyConfig(b.keyConfig)
+	select {
+	case err := <-reloads:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("keys not reloaded")
+	}
+	sig, err := m.Sign([]byte("msg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := b.SignPubKey.Verify([]byte("msg"), sig)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// invalid config is reported and current keys are kept
+	invalid := b.keyConfig.clone()
+	invalid.Seed = ""
+	invalid.PubC = a.keyConfig.PubC
+	provider.SetKeyConfig(invalid)
+	select {
+	case err := <-reloads:
+		var ve *ValidationError
+		assert.True(t, errors.As(err, &ve))
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload error not reported")
+	}
+	signKey, _ := m.ActiveKeys()
+	assert.True(t, signKey.PubKey.Equals(b.SignPubKey))
+
+	close(done)
+	wg.Wait()
+	stop()
+}
+
+func TestReloadKeysSnapshotFields(t *testing.T) {
+	a, err := NewMCryptFromSeed("test.io", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewMCryptFromSeed("test.io", []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := NewMemoryKeyProvider(a.keyConfig)
+	m, err := NewMCryptFromProvider(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signPriv, encPub := m.SignPrivKey, m.EncPubKey
+
+	// exported fields are read without locking while keys are swapped (run with -race)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				_, _ = m.SignPrivKey.Sign([]byte("msg"))
+				_ = m.SignPubKey
+				_ = m.EncPrivKey.Raw()
+				_ = m.EncPubKey.Raw()
+				_, _ = m.Sign([]byte("msg"))
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			provider.SetKeyConfig(b.keyConfig)
+		} else {
+			provider.SetKeyConfig(a.keyConfig)
+		}
+		assert.NoError(t, m.ReloadKeys())
+		if i%3 == 0 {
+			assert.NoError(t, m.RotateKeys())
+		}
+	}
+	provider.SetKeyConfig(b.keyConfig)
+	assert.NoError(t, m.ReloadKeys())
+	close(done)
+	wg.Wait()
+
+	// fields keep the keys MCrypt was created with, methods use the current keys
+	assert.True(t, m.SignPrivKey.Equals(signPriv))
+	assert.Equal(t, encPub.Raw(), m.EncPubKey.Raw())
+	signKey, _ := m.ActiveKeys()
+	bSign, _ := b.ActiveKeys()
+	assert.True(t, signKey.PubKey.Equals(bSign.PubKey))
+	sig, err := m.Sign([]byte("msg"))
+	assert.NoError(t, err)
+	valid, err := b.SignPubKey.Verify([]byte("msg"), sig)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
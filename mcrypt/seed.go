@@ -0,0 +1,107 @@
+package mcrypt
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"golang.org/x/crypto/hkdf"
+)
+
+const seedSecretKeyInfo = "mcrypt secret key"
+
+// configKeysFromSeed derives encoded signing, encryption and secret keys from a 32 byte seed.
+// Encryption keys are the X25519 conversion of the ed25519 keys
+func configKeysFromSeed(seed []byte) (*KeyConfig, error) {
+	priv, pub, privC, pubC, err := crypto.NewKeysFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	privBytes, err := priv.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	pubBytes, err := pub.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	secretKey := make([]byte, 32)
+	_, err = io.ReadFull(hkdf.New(sha256.New, seed, nil, []byte(seedSecretKeyInfo)), secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyConfig{
+		Seed:      crypto.ConfigEncodeKey(seed),
+		Priv:      crypto.ConfigEncodeKey(privBytes),
+		Pub:       crypto.ConfigEncodeKey(pubBytes),
+		PrivC:     crypto.ConfigEncodeEncryptKey(privC.Raw()),
+		PubC:      crypto.ConfigEncodeEncryptKey(pubC.Raw()),
+		SecretKey: crypto.ConfigEncodeAesKey(secretKey),
+	}, nil
+}
+
+// generateSeedConfigKeys returns key config with only new random seed and keys derived from it set
+func generateSeedConfigKeys() (*KeyConfig, error) {
+	seed, err := crypto.New32ByteKey()
+	if err != nil {
+		return nil, err
+	}
+	conf, err := configKeysFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	conf.Created = &now
+	return conf, nil
+}
+
+// expandSeed fills in keys derived from the seed. Public keys already present in the config must match
+func (conf *KeyConfig) expandSeed() error {
+	if conf.Seed == "" {
+		return nil
+	}
+	seed, err := crypto.ConfigDecodeKey(conf.Seed)
+	if err != nil {
+		return err
+	}
+	derived, err := configKeysFromSeed(seed)
+	if err != nil {
+		return err
+	}
+	if conf.Pub != "" && conf.Pub != derived.Pub || conf.PubC != "" && conf.PubC != derived.PubC {
+		return errors.New("Seed doesn't match public keys in config file")
+	}
+	conf.Priv = derived.Priv
+	conf.Pub = derived.Pub
+	conf.PrivC = derived.PrivC
+	conf.PubC = derived.PubC
+	conf.SecretKey = derived.SecretKey
+	return nil
+}
+
+/**
+* Generates a new file in single seed mode: signing, encryption and secret keys are all derived
+* from one random seed, only the seed and public keys are stored. Passphrase is optional
+**/
+func GenerateRandomSeedKeys(domain, outputfilepath, passphrase string) (*MCrypt, error) {
+	_, err := newKeyConfig(domain, outputfilepath, passphrase, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMCryptFromFileWithPassphrase(outputfilepath, passphrase)
+}
+
+/**
+* Creates MCrypt with all keys derived from a 32 byte seed
+**/
+func NewMCryptFromSeed(domain string, seed []byte) (*MCrypt, error) {
+	cfg := &KeyConfig{
+		Version: KeyConfigVersion,
+		Domain:  domain,
+		Seed:    crypto.ConfigEncodeKey(seed),
+	}
+	return NewMCryptFromConfig(cfg)
+}
@@ -0,0 +1,99 @@
+package mcrypt
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func TestGenerateSeedKeys(t *testing.T) {
+	defer cleanupfiles("test-seed.json")
+
+	generated, err := GenerateRandomSeedKeys("test.io", "test-seed.json", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadKeyConfigFromFile("test-seed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile("test-seed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the seed is stored, private keys are derived
+	assert.False(t, strings.Contains(string(content), cfg.Priv))
+	assert.False(t, strings.Contains(string(content), cfg.PrivC))
+
+	expectedPubC, err := crypto.Ed25519PublicKeyToCurve25519(generated.SignPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expectedPubC.Raw(), generated.EncPubKey.Raw())
+
+	seed, err := crypto.ConfigDecodeKey(cfg.Seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromSeed, err := NewMCryptFromSeed("test.io", seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, fromSeed.SignPrivKey.Equals(generated.SignPrivKey))
+	assert.Equal(t, generated.EncPrivKey.Raw(), fromSeed.EncPrivKey.Raw())
+
+	encrypted, err := generated.EncryptSymmetric([]byte("at rest"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := fromSeed.DecryptSymmetric(encrypted, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []byte("at rest"), decrypted)
+}
+
+func TestGenerateSeedKeysWithPassphrase(t *testing.T) {
+	defer cleanupfiles("test-seed-pass.json")
+
+	generated, err := GenerateRandomSeedKeys("test.io", "test-seed-pass.json", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadKeyConfigFromFile("test-seed-pass.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "", cfg.Seed)
+
+	m, err := NewMCryptFromFileWithPassphrase("test-seed-pass.json", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, m.SignPrivKey.Equals(generated.SignPrivKey))
+}
+
+func TestSeedMismatchingPublicKey(t *testing.T) {
+	seed, err := crypto.New32ByteKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pub, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubBytes, err := pub.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewMCryptFromConfig(&KeyConfig{
+		Domain: "test.io",
+		Seed:   crypto.ConfigEncodeKey(seed),
+		Pub:    crypto.ConfigEncodeKey(pubBytes),
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package mcrypt
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+)
+
+// DefaultSharedKeyCacheSize is the number of precomputed box shared keys (peer public key and own
+// encryption key pairs) each MCrypt keeps. 0 disables the cache
+var DefaultSharedKeyCacheSize = 1024
+
+// sharedKeyCache is a concurrency safe LRU cache of box shared keys
+type sharedKeyCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // most recently used first
+	entries  map[string]*list.Element
+}
+
+type sharedKeyEntry struct {
+	key       string
+	sharedKey [32]byte
+}
+
+func newSharedKeyCache(capacity int) *sharedKeyCache {
+	return &sharedKeyCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns a copy of the shared key of own encryption key and the peer public key, computing it on a miss.
+// Cached keys never leave the cache so callers can't modify them
+func (c *sharedKeyCache) get(ownID string, priv crypto.PrivCKey, peer crypto.PubCKey) [32]byte {
+	if c == nil || c.capacity <= 0 {
+		return *crypto.PrecomputeSharedKey(priv, peer)
+	}
+	key := ownID + string(peer.Raw()[:])
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*sharedKeyEntry).sharedKey
+	}
+	c.mu.Unlock()
+
+	// computed outside the lock, concurrent misses for the same pair compute the same value
+	sharedKey := *crypto.PrecomputeSharedKey(priv, peer)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*sharedKeyEntry).sharedKey
+	}
+	c.entries[key] = c.order.PushFront(&sharedKeyEntry{key: key, sharedKey: sharedKey})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*sharedKeyEntry).key)
+	}
+	return sharedKey
+}
+
+func (c *sharedKeyCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// purge drops all shared keys (keys rotated, revoked or reloaded)
+func (c *sharedKeyCache) purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// SetSharedKeyCacheSize changes the number of cached shared keys (0 disables the cache)
+func (m *MCrypt) SetSharedKeyCacheSize(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sharedKeys = newSharedKeyCache(size)
+}
+
+// SharedKey returns precomputed box shared key of the active encryption key and the peer public key (cached).
+// The returned key is a copy, changing it doesn't affect the cache
+func (m *MCrypt) SharedKey(peerPublicKey crypto.PubCKey) *[32]byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	active := m.encKeys[0]
+	sharedKey := m.sharedKeys.get(active.ID, active.PrivKey, peerPublicKey)
+	return &sharedKey
+}
@@ -0,0 +1,108 @@
+package mcrypt
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func TestSharedKeyCache(t *testing.T) {
+	priv, _, err := crypto.GenerateCryptKeys(rand.Reader)
+	assert.NoError(t, err)
+	peers := make([]crypto.PubCKey, 3)
+	for i := range peers {
+		_, peers[i], err = crypto.GenerateCryptKeys(rand.Reader)
+		assert.NoError(t, err)
+	}
+
+	cache := newSharedKeyCache(2)
+	k0 := cache.get("own", priv, peers[0])
+	assert.Equal(t, *crypto.PrecomputeSharedKey(priv, peers[0]), k0)
+	assert.Equal(t, 1, cache.len())
+	// callers get copies, the cached key can't be changed through them
+	k0[0] ^= 1
+	assert.Equal(t, *crypto.PrecomputeSharedKey(priv, peers[0]), cache.get("own", priv, peers[0]))
+	assert.Equal(t, 1, cache.len())
+	k0 = cache.get("own", priv, peers[0])
+	cache.get("own", priv, peers[1])
+	cache.get("own", priv, peers[0]) // peers[1] becomes least recently used
+	cache.get("own", priv, peers[2])
+	assert.Equal(t, 2, cache.len())
+	_, ok := cache.entries["own"+string(peers[0].Raw()[:])]
+	assert.True(t, ok, "recently used key kept")
+	_, ok = cache.entries["own"+string(peers[1].Raw()[:])]
+	assert.False(t, ok, "least recently used key evicted")
+	assert.Equal(t, k0, cache.get("own", priv, peers[0]))
+
+	cache.purge()
+	assert.Equal(t, 0, cache.len())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cache.get("own", priv, peers[i%len(peers)])
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 2, cache.len())
+}
+
+func TestEncryptCachedSharedKeys(t *testing.T) {
+	alice, err := NewMCryptFromSeed("alice.io", []byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+	bob, err := NewMCryptFromSeed("bob.io", []byte("fedcba9876543210fedcba9876543210"))
+	assert.NoError(t, err)
+
+	encrypted, err := alice.Encrypt(bob.EncPubKey, []byte("cached"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, alice.sharedKeys.len())
+	plain, err := bob.Decrypt(alice.EncPubKey, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "cached", string(plain))
+	assert.Equal(t, crypto.PrecomputeSharedKey(bob.EncPrivKey, alice.EncPubKey), bob.SharedKey(alice.EncPubKey))
+	sharedKey := bob.SharedKey(alice.EncPubKey)
+	sharedKey[0] ^= 1
+	assert.Equal(t, crypto.PrecomputeSharedKey(bob.EncPrivKey, alice.EncPubKey), bob.SharedKey(alice.EncPubKey))
+
+	// rotated keys purge the cache, old ciphertexts still decrypt with the retired key
+	assert.NoError(t, bob.RotateKeys())
+	assert.Equal(t, 0, bob.sharedKeys.len())
+	plain, err = bob.Decrypt(alice.EncPubKey, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "cached", string(plain))
+
+	// disabled cache
+	alice.SetSharedKeyCacheSize(0)
+	encrypted, err = alice.Encrypt(bob.EncPubKey, []byte("uncached"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, alice.sharedKeys.len())
+	plain, err = bob.Decrypt(alice.EncPubKey, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "uncached", string(plain))
+}
+
+func benchmarkMCryptEncrypt(b *testing.B, cacheSize int) {
+	alice, err := NewMCryptFromSeed("alice.io", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	alice.SetSharedKeyCacheSize(cacheSize)
+	_, peer, _ := crypto.GenerateCryptKeys(rand.Reader)
+	msg := make([]byte, 1024)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := alice.Encrypt(peer, msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncryptUncached(b *testing.B) { benchmarkMCryptEncrypt(b, 0) }
+
+func BenchmarkEncryptCached(b *testing.B) { benchmarkMCryptEncrypt(b, DefaultSharedKeyCacheSize) }
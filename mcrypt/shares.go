@@ -0,0 +1,55 @@
+package mcrypt
+
+import (
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+)
+
+// Split splits the key config (all private keys, secret key and keyring) into shares encoded as text.
+// Any threshold of them reconstruct the keys with NewMCryptFromShares. Encrypted configs must be decrypted first
+func (conf *KeyConfig) Split(threshold, shares int) ([]string, error) {
+	if conf.Encrypted != nil && conf.Priv == "" {
+		return nil, ErrPassphraseRequired
+	}
+	plain := *conf
+	plain.Encrypted = nil
+	configJSON, err := plain.marshal()
+	if err != nil {
+		return nil, err
+	}
+	split, err := crypto.SplitSecret(configJSON, threshold, shares)
+	if err != nil {
+		return nil, err
+	}
+	encoded := make([]string, len(split))
+	for i, share := range split {
+		encoded[i] = crypto.EncodeShare(share)
+	}
+	return encoded, nil
+}
+
+// SplitKeys splits domain keys among custodians, see KeyConfig.Split
+func (m *MCrypt) SplitKeys(threshold, shares int) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keyConfig.Split(threshold, shares)
+}
+
+/**
+* Reconstructs keys from at least threshold shares created by SplitKeys. Corrupted shares
+* and shares from different splits are reported as errors
+**/
+func NewMCryptFromShares(shares []string) (*MCrypt, error) {
+	decoded := make([][]byte, len(shares))
+	for i, share := range shares {
+		d, err := crypto.DecodeShare(share)
+		if err != nil {
+			return nil, err
+		}
+		decoded[i] = d
+	}
+	configJSON, err := crypto.CombineShares(decoded)
+	if err != nil {
+		return nil, err
+	}
+	return NewMCryptFromJSON(configJSON)
+}
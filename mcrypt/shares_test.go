@@ -0,0 +1,61 @@
+package mcrypt
+
+import (
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func TestSplitAndRestoreKeys(t *testing.T) {
+	defer cleanupfiles("test-shares.json")
+
+	generated, err := GenerateRandomKeys("test.io", "test-shares.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = generated.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares, err := generated.SplitKeys(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewMCryptFromShares([]string{shares[4], shares[0], shares[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signKey, encKey := generated.ActiveKeys()
+	assert.True(t, restored.SignPrivKey.Equals(signKey.PrivKey))
+	assert.Equal(t, encKey.PrivKey.Raw(), restored.EncPrivKey.Raw())
+	assert.Equal(t, generated.secretKey, restored.secretKey)
+	assert.Equal(t, 2, len(restored.SignKeys()))
+
+	_, err = NewMCryptFromShares(shares[:2])
+	assert.Equal(t, crypto.ErrNotEnoughShares, err)
+
+	corrupted := []byte(shares[1])
+	corrupted[20] = 'A' + (corrupted[20]-'A'+1)%26
+	_, err = NewMCryptFromShares([]string{shares[0], string(corrupted), shares[2]})
+	assert.Equal(t, crypto.ErrInvalidShare, err)
+}
+
+func TestSplitSeedKeys(t *testing.T) {
+	defer cleanupfiles("test-shares-seed.json")
+
+	generated, err := GenerateRandomSeedKeys("test.io", "test-shares-seed.json", "secret passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares, err := generated.SplitKeys(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewMCryptFromShares(shares[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, restored.SignPrivKey.Equals(generated.SignPrivKey))
+}
@@ -0,0 +1,118 @@
+package mcrypt
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	pb "github.com/igorrendulic/mcrypt-sdk-go/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// SignedEnvelopeVersion is the version of envelopes created by SealSigned
+const SignedEnvelopeVersion = 1
+
+const signedEnvelopeContext = "mcrypt signed envelope"
+
+// signedEnvelopeHeader returns the bytes signed together with the plaintext and bound to the encrypted body
+// as associated data: context, version, sender signing key and both encryption keys. Binding the recipient key
+// stops a recipient from re-encrypting a signed message to someone else as if it was sent to them
+func signedEnvelopeHeader(version uint32, sender *pb.PublicKey, senderEncKey, recipientEncKey []byte) []byte {
+	var header bytes.Buffer
+	header.WriteString(signedEnvelopeContext)
+	header.WriteByte(0)
+	binary.Write(&header, binary.BigEndian, version)
+	header.WriteByte(byte(sender.GetType()))
+	header.Write(sender.GetData())
+	header.Write(senderEncKey)
+	header.Write(recipientEncKey)
+	return header.Bytes()
+}
+
+// SealSigned signs plaintext with the active signing key and encrypts it with the active encryption key for the
+// recipient (sign-then-encrypt). The signature covers the plaintext, the sender keys and the recipient key
+func (m *MCrypt) SealSigned(recipientPublicKey crypto.PubCKey, plaintext []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	signKey, encKey := m.signKeys[0], m.encKeys[0]
+	senderRaw, err := signKey.PubKey.Raw()
+	if err != nil {
+		return nil, err
+	}
+	envelope := &pb.SignedEnvelope{
+		Version:         SignedEnvelopeVersion,
+		Sender:          &pb.PublicKey{Type: signKey.PubKey.Type(), Data: senderRaw},
+		SenderEncKey:    encKey.PubKey.Raw()[:],
+		RecipientEncKey: recipientPublicKey.Raw()[:],
+	}
+	header := signedEnvelopeHeader(envelope.Version, envelope.Sender, envelope.SenderEncKey, envelope.RecipientEncKey)
+
+	signature, err := signKey.PrivKey.Sign(append(header, plaintext...))
+	if err != nil {
+		return nil, err
+	}
+	body, err := proto.Marshal(&pb.SignedBody{Plaintext: plaintext, Signature: signature})
+	if err != nil {
+		return nil, err
+	}
+	envelope.Body, err = crypto.EncryptWithAD(encKey.PrivKey, recipientPublicKey, body, header)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(envelope)
+}
+
+// OpenVerified decrypts envelope created by SealSigned and verifies the sender signature. Returns the sender
+// signing public key and the plaintext. Whether the sender is trusted (e.g. key published by its domain) is
+// up to the caller. Errors: ErrInvalidSignedEnvelope, ErrUnsupportedEnvelopeVersion, crypto.ErrNotRecipient
+// (sealed for another key or with a revoked key), crypto.ErrDecryptionFailed and ErrInvalidSignature
+func (m *MCrypt) OpenVerified(envelope []byte) (crypto.PubKey, []byte, error) {
+	var env pb.SignedEnvelope
+	if err := proto.Unmarshal(envelope, &env); err != nil {
+		return nil, nil, ErrInvalidSignedEnvelope
+	}
+	if env.Version != SignedEnvelopeVersion {
+		return nil, nil, ErrUnsupportedEnvelopeVersion
+	}
+	if env.Sender == nil || len(env.SenderEncKey) != 32 || len(env.RecipientEncKey) != 32 {
+		return nil, nil, ErrInvalidSignedEnvelope
+	}
+	unmarshal, ok := crypto.PubKeyUnmarshallers[env.Sender.Type]
+	if !ok {
+		return nil, nil, ErrInvalidSignedEnvelope
+	}
+	sender, err := unmarshal(env.Sender.Data)
+	if err != nil {
+		return nil, nil, ErrInvalidSignedEnvelope
+	}
+
+	m.mu.RLock()
+	var recipient *EncKey
+	for _, k := range m.encKeys {
+		if k.State != KeyStateRevoked && bytes.Equal(k.PubKey.Raw()[:], env.RecipientEncKey) {
+			recipient = k
+			break
+		}
+	}
+	m.mu.RUnlock()
+	if recipient == nil {
+		return nil, nil, crypto.ErrNotRecipient
+	}
+
+	var senderEncKey [32]byte
+	copy(senderEncKey[:], env.SenderEncKey)
+	header := signedEnvelopeHeader(env.Version, env.Sender, env.SenderEncKey, env.RecipientEncKey)
+	plainBody, err := crypto.DecryptWithAD(recipient.PrivKey, &crypto.Curve25519PublicKey{Key: &senderEncKey}, env.Body, header)
+	if err != nil {
+		return nil, nil, crypto.ErrDecryptionFailed
+	}
+	var body pb.SignedBody
+	if err := proto.Unmarshal(plainBody, &body); err != nil {
+		return nil, nil, ErrInvalidSignedEnvelope
+	}
+	valid, err := sender.Verify(append(header, body.Plaintext...), body.Signature)
+	if err != nil || !valid {
+		return nil, nil, ErrInvalidSignature
+	}
+	return sender, body.Plaintext, nil
+}
@@ -0,0 +1,93 @@
+package mcrypt
+
+import (
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	pb "github.com/igorrendulic/mcrypt-sdk-go/proto"
+	"github.com/tj/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSealSignedOpenVerified(t *testing.T) {
+	alice, err := NewMCryptFromSeed("alice.io", []byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+	bob, err := NewMCryptFromSeed("bob.io", []byte("fedcba9876543210fedcba9876543210"))
+	assert.NoError(t, err)
+	carol, err := NewMCryptFromSeed("carol.io", []byte("abcdef0123456789abcdef0123456789"))
+	assert.NoError(t, err)
+
+	envelope, err := alice.SealSigned(bob.EncPubKey, []byte("server to server"))
+	assert.NoError(t, err)
+
+	sender, plain, err := bob.OpenVerified(envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, "server to server", string(plain))
+	assert.True(t, sender.Equals(alice.SignPubKey))
+
+	_, _, err = carol.OpenVerified(envelope)
+	assert.Equal(t, crypto.ErrNotRecipient, err)
+
+	// still opens with the retired key after rotation
+	assert.NoError(t, bob.RotateKeys())
+	_, plain, err = bob.OpenVerified(envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, "server to server", string(plain))
+
+	_, _, err = bob.OpenVerified([]byte("not an envelope"))
+	assert.Equal(t, ErrInvalidSignedEnvelope, err)
+
+	var env pb.SignedEnvelope
+	assert.NoError(t, proto.Unmarshal(envelope, &env))
+	tampered := proto.Clone(&env).(*pb.SignedEnvelope)
+	tampered.Version = SignedEnvelopeVersion + 1
+	_, _, err = bob.OpenVerified(marshalSigned(t, tampered))
+	assert.Equal(t, ErrUnsupportedEnvelopeVersion, err)
+
+	tampered = proto.Clone(&env).(*pb.SignedEnvelope)
+	tampered.Body[len(tampered.Body)-1] ^= 1
+	_, _, err = bob.OpenVerified(marshalSigned(t, tampered))
+	assert.Equal(t, crypto.ErrDecryptionFailed, err)
+
+	// claiming another sender changes the header the body is bound to
+	tampered = proto.Clone(&env).(*pb.SignedEnvelope)
+	carolSign, err := carol.SignPubKey.Raw()
+	assert.NoError(t, err)
+	tampered.Sender.Data = carolSign
+	_, _, err = bob.OpenVerified(marshalSigned(t, tampered))
+	assert.Equal(t, crypto.ErrDecryptionFailed, err)
+}
+
+func TestOpenVerifiedForwarded(t *testing.T) {
+	alice, err := NewMCryptFromSeed("alice.io", []byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+	bob, err := NewMCryptFromSeed("bob.io", []byte("fedcba9876543210fedcba9876543210"))
+	assert.NoError(t, err)
+	carol, err := NewMCryptFromSeed("carol.io", []byte("abcdef0123456789abcdef0123456789"))
+	assert.NoError(t, err)
+
+	envelope, err := alice.SealSigned(bob.EncPubKey, []byte("for bob only"))
+	assert.NoError(t, err)
+
+	// bob re-encrypts alice's signed body to carol
+	var env pb.SignedEnvelope
+	assert.NoError(t, proto.Unmarshal(envelope, &env))
+	header := signedEnvelopeHeader(env.Version, env.Sender, env.SenderEncKey, env.RecipientEncKey)
+	body, err := crypto.DecryptWithAD(bob.EncPrivKey, alice.EncPubKey, env.Body, header)
+	assert.NoError(t, err)
+
+	env.SenderEncKey = bob.EncPubKey.Raw()[:]
+	env.RecipientEncKey = carol.EncPubKey.Raw()[:]
+	header = signedEnvelopeHeader(env.Version, env.Sender, env.SenderEncKey, env.RecipientEncKey)
+	env.Body, err = crypto.EncryptWithAD(bob.EncPrivKey, carol.EncPubKey, body, header)
+	assert.NoError(t, err)
+
+	_, _, err = carol.OpenVerified(marshalSigned(t, &env))
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func marshalSigned(t *testing.T, env *pb.SignedEnvelope) []byte {
+	out, err := proto.Marshal(env)
+	assert.NoError(t, err)
+	return out
+}
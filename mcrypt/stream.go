@@ -0,0 +1,105 @@
+package mcrypt
+
+import (
+	"io"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+)
+
+// EncryptStream returns writer encrypting a large payload for the recipient into w (chunked AES-256-GCM with
+// the key agreed between the active encryption key and recipientPublicKey). Close writes the final chunk
+func (m *MCrypt) EncryptStream(recipientPublicKey crypto.PubCKey, w io.Writer) (io.WriteCloser, error) {
+	return m.EncryptStreamWithAD(recipientPublicKey, w, nil)
+}
+
+// EncryptStreamWithAD is EncryptStream binding the stream to associatedData (e.g. attachment ID)
+func (m *MCrypt) EncryptStreamWithAD(recipientPublicKey crypto.PubCKey, w io.Writer, associatedData []byte) (io.WriteCloser, error) {
+	m.mu.RLock()
+	priv := m.encKeys[0].PrivKey
+	m.mu.RUnlock()
+	key, err := crypto.Curve25519SharedKey(priv, recipientPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewAes256EncryptWriterWithAD(w, key, associatedData)
+}
+
+// DecryptStream returns reader decrypting stream from the sender, trying the active and then the retired encryption keys
+func (m *MCrypt) DecryptStream(senderPublicKey crypto.PubCKey, r io.Reader) (io.Reader, error) {
+	return m.DecryptStreamWithAD(senderPublicKey, r, nil)
+}
+
+// DecryptStreamWithAD decrypts stream created by EncryptStreamWithAD with the same associatedData
+func (m *MCrypt) DecryptStreamWithAD(senderPublicKey crypto.PubCKey, r io.Reader, associatedData []byte) (io.Reader, error) {
+	keys, err := m.streamKeys(senderPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewAes256DecryptReaderWithKeys(r, keys, associatedData)
+}
+
+// DecryptStreamAt returns random access decryptor of stream from the sender (e.g. HTTP range requests)
+func (m *MCrypt) DecryptStreamAt(senderPublicKey crypto.PubCKey, r io.ReaderAt, size int64) (*crypto.Aes256ReaderAt, error) {
+	return m.DecryptStreamAtWithAD(senderPublicKey, r, size, nil)
+}
+
+// DecryptStreamAtWithAD is DecryptStreamAt for streams created by EncryptStreamWithAD
+func (m *MCrypt) DecryptStreamAtWithAD(senderPublicKey crypto.PubCKey, r io.ReaderAt, size int64, associatedData []byte) (*crypto.Aes256ReaderAt, error) {
+	keys, err := m.streamKeys(senderPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewAes256DecryptReaderAtWithKeys(r, size, keys, associatedData)
+}
+
+// streamKeys returns keys agreed with the sender for all non revoked encryption keys
+func (m *MCrypt) streamKeys(senderPublicKey crypto.PubCKey) ([][]byte, error) {
+	m.mu.RLock()
+	encKeys := m.encKeys
+	m.mu.RUnlock()
+	var keys [][]byte
+	for _, k := range encKeys {
+		if k.State == KeyStateRevoked {
+			continue
+		}
+		key, err := crypto.Curve25519SharedKey(k.PrivKey, senderPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// EncryptSymmetricStream returns writer encrypting into w with the domain secret key (see EncryptSymmetric)
+func (m *MCrypt) EncryptSymmetricStream(w io.Writer) (io.WriteCloser, error) {
+	m.mu.RLock()
+	secretKey := m.secretKey
+	m.mu.RUnlock()
+	if secretKey == nil {
+		return nil, ErrSecretKeyMissing
+	}
+	return crypto.NewAes256EncryptWriter(w, secretKey)
+}
+
+// DecryptSymmetricStream returns reader decrypting stream created by EncryptSymmetricStream
+func (m *MCrypt) DecryptSymmetricStream(r io.Reader) (io.Reader, error) {
+	m.mu.RLock()
+	secretKey := m.secretKey
+	m.mu.RUnlock()
+	if secretKey == nil {
+		return nil, ErrSecretKeyMissing
+	}
+	return crypto.NewAes256DecryptReader(r, secretKey)
+}
+
+// DecryptSymmetricStreamAt returns random access decryptor of stream created by EncryptSymmetricStream
+func (m *MCrypt) DecryptSymmetricStreamAt(r io.ReaderAt, size int64) (*crypto.Aes256ReaderAt, error) {
+	m.mu.RLock()
+	secretKey := m.secretKey
+	m.mu.RUnlock()
+	if secretKey == nil {
+		return nil, ErrSecretKeyMissing
+	}
+	return crypto.NewAes256DecryptReaderAt(r, size, secretKey)
+}
@@ -0,0 +1,78 @@
+package mcrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestEncryptStream(t *testing.T) {
+	alice, err := NewMCryptFromSeed("alice.io", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewMCryptFromSeed("bob.io", []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := make([]byte, 200*1024)
+	rand.Read(plain)
+
+	var buf bytes.Buffer
+	w, err := alice.EncryptStream(bob.EncPubKey, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.Copy(w, bytes.NewReader(plain))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	encrypted := buf.Bytes()
+
+	// bob rotated keys after the stream was encrypted
+	err = bob.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := bob.DecryptStream(alice.EncPubKey, bytes.NewReader(encrypted))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(plain, decrypted))
+
+	ra, err := bob.DecryptStreamAt(alice.EncPubKey, bytes.NewReader(encrypted), int64(len(encrypted)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	part := make([]byte, 1000)
+	_, err = ra.ReadAt(part, 100*1024)
+	assert.NoError(t, err)
+	assert.Equal(t, plain[100*1024:100*1024+1000], part)
+
+	buf.Reset()
+	w, err = alice.EncryptSymmetricStream(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Write(plain)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	symmetric := append([]byte(nil), buf.Bytes()...)
+	ra, err = alice.DecryptSymmetricStreamAt(bytes.NewReader(symmetric), int64(len(symmetric)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(len(plain)), ra.Size())
+	r, err = alice.DecryptSymmetricStream(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err = ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(plain, decrypted))
+}
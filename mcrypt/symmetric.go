@@ -0,0 +1,28 @@
+package mcrypt
+
+import "github.com/igorrendulic/mcrypt-sdk-go/crypto"
+
+// EncryptSymmetric encrypts plaintext with the domain secret key (AES-256 GCM). associatedData (e.g. row ID)
+// is authenticated but not encrypted and must be provided again for decryption. It can be nil
+func (m *MCrypt) EncryptSymmetric(plaintext, associatedData []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.secretKey == nil {
+		return nil, ErrSecretKeyMissing
+	}
+	return crypto.Aes256EncryptWithAD(m.secretKey, plaintext, associatedData)
+}
+
+// DecryptSymmetric decrypts ciphertext created by EncryptSymmetric with the same associatedData
+func (m *MCrypt) DecryptSymmetric(ciphertext, associatedData []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.secretKey == nil {
+		return nil, ErrSecretKeyMissing
+	}
+	plain, err := crypto.Aes256DecryptWithAD(m.secretKey, ciphertext, associatedData)
+	if err != nil {
+		return nil, crypto.ErrDecryptionFailed
+	}
+	return plain, nil
+}
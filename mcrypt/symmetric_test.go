@@ -0,0 +1,56 @@
+package mcrypt
+
+import (
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func TestSymmetricEncryption(t *testing.T) {
+	defer cleanupfiles("test-symmetric.json")
+
+	mcrypt, err := GenerateRandomKeys("test.io", "test-symmetric.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("at rest data")
+	encrypted, err := mcrypt.EncryptSymmetric(msg, []byte("row-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewMCryptFromFile("test-symmetric.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := reloaded.DecryptSymmetric(encrypted, []byte("row-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, msg, decrypted)
+
+	_, err = reloaded.DecryptSymmetric(encrypted, []byte("row-2"))
+	assert.Equal(t, crypto.ErrDecryptionFailed, err)
+}
+
+func TestSymmetricEncryptionWithoutSecretKey(t *testing.T) {
+	defer cleanupfiles("test-symmetric.json")
+
+	generated, err := GenerateRandomKeys("test.io", "test-symmetric.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := *generated.keyConfig
+	cfg.SecretKey = ""
+	mcrypt, err := NewMCryptFromConfig(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = mcrypt.EncryptSymmetric([]byte("at rest data"), nil)
+	assert.Equal(t, ErrSecretKeyMissing, err)
+
+	cfg.SecretKey = crypto.ConfigEncodeAesKey([]byte("short"))
+	_, err = NewMCryptFromConfig(&cfg)
+	assert.Error(t, err)
+}
@@ -1,26 +1,168 @@
 package mcrypt
 
 import (
+	"log"
+	"sync"
+	"time"
+
 	crypt "github.com/igorrendulic/mcrypt-sdk-go/crypto"
 )
 
+// StrictKeyFilePermissions refuses to load key files readable by group or others (default only reports
+// them to OnInsecureKeyFile)
+var StrictKeyFilePermissions = false
+
+// OnInsecureKeyFile is called with ErrInsecureKeyFile wrapping error for key files readable by group or others
+// when StrictKeyFilePermissions is off. Default logs a warning with the standard logger, nil disables warnings
+var OnInsecureKeyFile = func(err error) {
+	log.Printf("mcrypt: warning: %v", err)
+}
+
+// Environment variables read by NewMCryptFromEnv
+const (
+	EnvKeyConfig  = "MCRYPT_KEY_CONFIG" // whole JSON key config
+	EnvPassphrase = "MCRYPT_PASSPHRASE" // passphrase for encrypted key config
+	EnvDomain     = "MCRYPT_DOMAIN"
+	EnvPub        = "MCRYPT_PUB"
+	EnvPriv       = "MCRYPT_PRIV"
+	EnvPubC       = "MCRYPT_PUBC"
+	EnvPrivC      = "MCRYPT_PRIVC"
+	EnvSecretKey  = "MCRYPT_SECRET_KEY"
+	EnvSeed       = "MCRYPT_SEED" // single seed key config mode
+)
+
+// MCrypt holds the domain keys. Methods are safe for concurrent use, also while keys are rotated or reloaded
+// (RotateKeys, ReloadKeys, WatchKeys) and always use the current keys.
+//
+// SignPrivKey, SignPubKey, EncPrivKey and EncPubKey are the active keys at the time MCrypt was created. They
+// are never modified afterwards (safe to read without locking), RotateKeys, RevokeKey and reloads have no
+// effect on them. Use ActiveKeys for the current keys
 type MCrypt struct {
+	// Deprecated: signing key at the time MCrypt was created, not updated by RotateKeys, RevokeKey or
+	// reloads. Use Sign, or ActiveKeys for the current key
+	SignPrivKey crypt.PrivKey
+	// Deprecated: signing public key at the time MCrypt was created, not updated by RotateKeys, RevokeKey
+	// or reloads. Use Verify, or ActiveKeys for the current key
+	SignPubKey crypt.PubKey
+	// Deprecated: encryption key at the time MCrypt was created, not updated by RotateKeys, RevokeKey or
+	// reloads. Use Encrypt and Decrypt, or ActiveKeys for the current key
+	EncPrivKey crypt.PrivCKey
+	// Deprecated: encryption public key at the time MCrypt was created, not updated by RotateKeys,
+	// RevokeKey or reloads. Use ActiveKeys for the current key
+	EncPubKey  crypt.PubCKey
+	keyConfig  *KeyConfig
+	provider   KeyProvider // source of the keys, nil when created from a config
+	mu         sync.RWMutex
+	signKeys   []*SignKey      // active key first, then retired and revoked keys from the keyring
+	encKeys    []*EncKey       // active key first, then retired and revoked keys from the keyring
+	secretKey  []byte          // domain AES-256 key for symmetric encryption
+	sharedKeys *sharedKeyCache // precomputed box shared keys, purged when keys change
+}
+
+// KeyState of a key in the keyring
+type KeyState string
+
+const (
+	// KeyStateActive key is used for signing and encryption
+	KeyStateActive KeyState = "active"
+	// KeyStateRetired key is only used to verify signatures and decrypt
+	KeyStateRetired KeyState = "retired"
+	// KeyStateRevoked key is kept for the record but is never used
+	KeyStateRevoked KeyState = "revoked"
+)
+
+// SignKey is an ed25519 signing key from the keyring
+type SignKey struct {
+	ID      string
+	Created time.Time
+	State   KeyState
+	PrivKey crypt.PrivKey
+	PubKey  crypt.PubKey
+}
+
+// EncKey is a curve25519 encryption key from the keyring
+type EncKey struct {
+	ID      string
+	Created time.Time
+	State   KeyState
+	PrivKey crypt.PrivCKey
+	PubKey  crypt.PubCKey
+}
+
+// DerivedKeys are signing and encryption subkeys derived from the domain signing key for a path
+type DerivedKeys struct {
+	Path        string
+	KeyID       string // signing key the subkeys were derived from
 	SignPrivKey crypt.PrivKey
 	SignPubKey  crypt.PubKey
 	EncPrivKey  crypt.PrivCKey
 	EncPubKey   crypt.PubCKey
-	keyConfig   *KeyConfig
 }
 
 // KeyConfig for JSON Configuration file (stored under home folder .dtable)
 type KeyConfig struct {
+	Version   int    `json:"version"`
 	Pub       string `json:"pub"`
 	Priv      string `json:"priv"`
 	PubC      string `json:"pubC"`
 	PrivC     string `json:"privC"`
 	Domain    string `json:"domain"`
 	SecretKey string `json:"secretKey"`
-	filePath  string
+	// Seed (single seed mode) derives priv, pub, privC, pubC and secretKey. Only seed and public keys are stored
+	Seed string `json:"seed,omitempty"`
+	// MnemonicEntropy is set when seed was derived from a mnemonic with a mnemonic passphrase (BIP-39 seed).
+	// ExportMnemonic returns the mnemonic, restoring it requires the same passphrase
+	MnemonicEntropy string `json:"mnemonicEntropy,omitempty"`
+	// Created is the creation time of the active keys (pub, priv, pubC, privC)
+	Created *time.Time `json:"created,omitempty"`
+	// Keyring holds previous (retired or revoked) keys. Active keys are always pub, priv, pubC and privC
+	Keyring *Keyring `json:"keyring,omitempty"`
+	// DeriveRoot is the ID of the signing key DeriveKeys derives subkeys from. Empty until the first rotation
+	// (active signing key), then the keyring ID of the original key so derived subkeys survive rotation
+	DeriveRoot string `json:"deriveRoot,omitempty"`
+	// Encrypted holds passphrase protected priv, privC, secretKey, seed, mnemonicEntropy and keyring private keys (those fields are then empty in the file)
+	Encrypted *EncryptedKeys `json:"encrypted,omitempty"`
+	// Checksum is HMAC of the config content keyed by the private keys (detects truncated or edited files)
+	Checksum string `json:"checksum,omitempty"`
+	filePath string
+	migrated bool // loaded from an older schema version
+}
+
+// Keyring of previous signing and encryption keys
+type Keyring struct {
+	Sign []KeyringEntry `json:"sign,omitempty"`
+	Enc  []KeyringEntry `json:"enc,omitempty"`
+}
+
+// KeyringEntry is a single key in the keyring. Pub and Priv are encoded the same way as the active keys
+type KeyringEntry struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	State   KeyState  `json:"state"`
+	Pub     string    `json:"pub"`
+	Priv    string    `json:"priv"`
+}
+
+// EncryptedKeys are the secret fields of KeyConfig sealed with a passphrase derived key
+type EncryptedKeys struct {
+	KDF        string `json:"kdf"`
+	Salt       string `json:"salt"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"`
+	Threads    uint8  `json:"threads"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// secretKeys is the plaintext sealed in EncryptedKeys.Ciphertext
+type secretKeys struct {
+	Priv      string `json:"priv"`
+	PrivC     string `json:"privC"`
+	SecretKey string `json:"secretKey"`
+	Seed      string `json:"seed,omitempty"`
+	// MnemonicEntropy of seeds derived with a mnemonic passphrase
+	MnemonicEntropy string `json:"mnemonicEntropy,omitempty"`
+	// Keyring private keys by key ID
+	Keyring map[string]string `json:"keyring,omitempty"`
 }
 
 type Key struct {
@@ -0,0 +1,219 @@
+package mcrypt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	pb "github.com/igorrendulic/mcrypt-sdk-go/proto"
+	"golang.org/x/crypto/curve25519"
+)
+
+// FieldError is a problem with a single key config field. Field is the JSON field name (e.g. privC, keyring.sign[0].pub)
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists every problem found in a key config
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "invalid key config: " + strings.Join(msgs, "; ")
+}
+
+// Field returns the error for JSON field name or nil
+func (e *ValidationError) Field(name string) *FieldError {
+	for _, fe := range e.Errors {
+		if fe.Field == name {
+			return fe
+		}
+	}
+	return nil
+}
+
+type validator struct {
+	errs []*FieldError
+}
+
+func (v *validator) add(field string, err error) {
+	v.errs = append(v.errs, &FieldError{Field: field, Err: err})
+}
+
+func (v *validator) addf(field, format string, args ...interface{}) {
+	v.add(field, fmt.Errorf(format, args...))
+}
+
+// signKeys decodes and cross checks ed25519 key pair. Returns nil public key if it can't be decoded
+func (v *validator) signKeys(pubField, privField, pub, priv string) crypto.PubKey {
+	var pubKey crypto.PubKey
+	var privKey crypto.PrivKey
+	if pub == "" {
+		v.addf(pubField, "missing")
+	} else if raw, err := crypto.ConfigDecodeKey(pub); err != nil {
+		v.add(pubField, err)
+	} else if pubKey, err = crypto.UnmarshalPublicKey(raw); err != nil {
+		v.add(pubField, err)
+	} else if pubKey.Type() != pb.KeyType_Ed25519 {
+		v.add(pubField, crypto.ErrBadKeyType)
+		pubKey = nil
+	}
+	if priv == "" {
+		v.addf(privField, "missing")
+	} else if raw, err := crypto.ConfigDecodeKey(priv); err != nil {
+		v.add(privField, err)
+	} else if privKey, err = crypto.UnmarshalEd25519PrivateKey(raw); err != nil {
+		v.add(privField, err)
+	}
+	if pubKey != nil && privKey != nil && !privKey.GetPublic().Equals(pubKey) {
+		v.addf(pubField, "doesn't match %s", privField)
+	}
+	return pubKey
+}
+
+// encKeys decodes and cross checks X25519 key pair. Returns nil public key if it can't be decoded
+func (v *validator) encKeys(pubField, privField, pub, priv string) crypto.PubCKey {
+	var pubKey, privKey *[32]byte
+	var err error
+	if pub == "" {
+		v.addf(pubField, "missing")
+	} else if pubKey, err = crypto.ConfigDecodeEncryptKey(pub); err != nil {
+		v.add(pubField, err)
+	}
+	if priv == "" {
+		v.addf(privField, "missing")
+	} else if privKey, err = crypto.ConfigDecodeEncryptKey(priv); err != nil {
+		v.add(privField, err)
+	}
+	if pubKey == nil {
+		return nil
+	}
+	if privKey != nil {
+		derived, err := curve25519.X25519(privKey[:], curve25519.Basepoint)
+		if err != nil {
+			v.add(privField, err)
+		} else if !bytes.Equal(derived, pubKey[:]) {
+			v.addf(pubField, "is not the X25519 public key of %s", privField)
+		}
+	}
+	return &crypto.Curve25519PublicKey{Key: pubKey}
+}
+
+func (v *validator) keyringEntry(field string, entry KeyringEntry, id func() (string, error)) {
+	switch entry.State {
+	case KeyStateRetired, KeyStateRevoked:
+	case KeyStateActive:
+		// only pub, priv, pubC and privC are active
+		v.addf(field+".state", "keyring keys can only be retired or revoked")
+	default:
+		v.addf(field+".state", "unknown key state %q", entry.State)
+	}
+	if id == nil {
+		return
+	}
+	expected, err := id()
+	if err != nil {
+		v.add(field+".pub", err)
+	} else if entry.ID != expected {
+		v.addf(field+".id", "doesn't match pub (expected %s)", expected)
+	}
+}
+
+// Validate decodes every key in the config and checks lengths and that key pairs belong together:
+// pub is the public key of priv, pubC is the X25519 public key of privC (same for keyring entries).
+// All problems are returned as *ValidationError. Encrypted configs must be decrypted first
+func (conf *KeyConfig) Validate() error {
+	if conf.Encrypted != nil && conf.Priv == "" {
+		return ErrPassphraseRequired
+	}
+	v := &validator{}
+	if conf.Domain == "" {
+		v.addf("domain", "missing")
+	}
+	v.signKeys("pub", "priv", conf.Pub, conf.Priv)
+	v.encKeys("pubC", "privC", conf.PubC, conf.PrivC)
+
+	// secret key is optional, key files created before it was generated don't have it
+	if conf.SecretKey != "" {
+		secretKey, err := crypto.ConfigDecodeAesKey(conf.SecretKey)
+		if err != nil {
+			v.add("secretKey", err)
+		} else if len(secretKey) != 32 {
+			v.addf("secretKey", "must be 32 bytes long, got %d", len(secretKey))
+		}
+	}
+	if conf.Seed != "" {
+		seed, err := crypto.ConfigDecodeKey(conf.Seed)
+		if err != nil {
+			v.add("seed", err)
+		} else if len(seed) != 32 {
+			v.addf("seed", "must be 32 bytes long, got %d", len(seed))
+		} else if derived, err := configKeysFromSeed(seed); err != nil {
+			v.add("seed", err)
+		} else if derived.Pub != conf.Pub || derived.PubC != conf.PubC {
+			v.addf("seed", "doesn't match public keys")
+		}
+	}
+	if conf.DeriveRoot != "" {
+		found := false
+		if conf.Keyring != nil {
+			for _, entry := range conf.Keyring.Sign {
+				found = found || entry.ID == conf.DeriveRoot
+			}
+		}
+		if !found {
+			v.addf("deriveRoot", "key %s not in the signing keyring", conf.DeriveRoot)
+		}
+	}
+	if conf.MnemonicEntropy != "" {
+		entropy, err := crypto.ConfigDecodeKey(conf.MnemonicEntropy)
+		if err != nil {
+			v.add("mnemonicEntropy", err)
+		} else if len(entropy) != 32 {
+			v.addf("mnemonicEntropy", "must be 32 bytes long, got %d", len(entropy))
+		} else if conf.Seed == "" {
+			v.addf("mnemonicEntropy", "requires seed")
+		}
+	}
+
+	if conf.Keyring != nil {
+		for i, entry := range conf.Keyring.Sign {
+			field := fmt.Sprintf("keyring.sign[%d]", i)
+			pub := v.signKeys(field+".pub", field+".priv", entry.Pub, entry.Priv)
+			var id func() (string, error)
+			if pub != nil {
+				id = func() (string, error) { return signKeyID(pub) }
+			}
+			v.keyringEntry(field, entry, id)
+		}
+		for i, entry := range conf.Keyring.Enc {
+			field := fmt.Sprintf("keyring.enc[%d]", i)
+			pub := v.encKeys(field+".pub", field+".priv", entry.Pub, entry.Priv)
+			var id func() (string, error)
+			if pub != nil {
+				id = func() (string, error) { return encKeyID(pub), nil }
+			}
+			v.keyringEntry(field, entry, id)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return &ValidationError{Errors: v.errs}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package mcrypt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+)
+
+func TestValidateKeyConfig(t *testing.T) {
+	cfg, err := generateConfigKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Domain = "test.io"
+	assert.NoError(t, cfg.Validate())
+
+	other, err := generateConfigKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every problem is reported with its JSON field name
+	bad := cfg.clone()
+	bad.Domain = ""
+	bad.Priv = "not base64!"
+	bad.PubC = other.PubC
+	bad.SecretKey = crypto.ConfigEncodeAesKey([]byte("short"))
+	err = bad.Validate()
+	var ve *ValidationError
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, 4, len(ve.Errors))
+	assert.NotNil(t, ve.Field("domain"))
+	assert.NotNil(t, ve.Field("priv"))
+	assert.NotNil(t, ve.Field("pubC"))
+	assert.NotNil(t, ve.Field("secretKey"))
+	assert.Contains(t, err.Error(), "pubC: is not the X25519 public key of privC")
+
+	// mismatched ed25519 pair
+	bad = cfg.clone()
+	bad.Pub = other.Pub
+	ve = bad.Validate().(*ValidationError)
+	assert.EqualError(t, ve.Field("pub"), "pub: doesn't match priv")
+
+	// short encryption key doesn't panic
+	bad = cfg.clone()
+	bad.PrivC = crypto.ConfigEncodeKey([]byte("short"))
+	ve = bad.Validate().(*ValidationError)
+	assert.NotNil(t, ve.Field("privC"))
+
+	_, err = NewMCryptFromConfig(bad)
+	assert.True(t, errors.As(err, &ve))
+}
+
+func TestValidateKeyring(t *testing.T) {
+	m, err := NewMCryptFromSeed("test.io", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, m.keyConfig.Validate())
+
+	bad := m.keyConfig.clone()
+	bad.Keyring.Sign[0].ID = "0000000000000000"
+	bad.Keyring.Enc[0].Priv = bad.PrivC
+	bad.Keyring.Enc[0].State = "lost"
+	ve := bad.Validate().(*ValidationError)
+	assert.Equal(t, 4, len(ve.Errors))
+	assert.NotNil(t, ve.Field("keyring.sign[0].id"))
+	assert.NotNil(t, ve.Field("deriveRoot"))
+	assert.NotNil(t, ve.Field("keyring.enc[0].pub"))
+	assert.NotNil(t, ve.Field("keyring.enc[0].state"))
+
+	// second active key
+	bad = m.keyConfig.clone()
+	bad.Keyring.Sign[0].State = KeyStateActive
+	ve = bad.Validate().(*ValidationError)
+	assert.Equal(t, 1, len(ve.Errors))
+	assert.EqualError(t, ve.Field("keyring.sign[0].state"), "keyring.sign[0].state: keyring keys can only be retired or revoked")
+}
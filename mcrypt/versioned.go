@@ -0,0 +1,97 @@
+package mcrypt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+)
+
+// DefaultSymmetricSuite is the cipher suite of EncryptVersionedSymmetric
+var DefaultSymmetricSuite = crypto.SuiteAes256GCM
+
+// secretKeyID identifies the secret key in versioned ciphertexts without revealing it
+func secretKeyID(secretKey []byte) string {
+	h := sha256.Sum256(append([]byte("mcrypt secret key id:"), secretKey...))
+	return hex.EncodeToString(h[:8])
+}
+
+// EncryptVersionedSymmetric encrypts plaintext with the domain secret key and DefaultSymmetricSuite into
+// a self describing ciphertext (suite and key ID in the header), see DecryptVersioned
+func (m *MCrypt) EncryptVersionedSymmetric(plaintext, associatedData []byte) ([]byte, error) {
+	return m.EncryptVersionedSymmetricWithSuite(DefaultSymmetricSuite, plaintext, associatedData)
+}
+
+// EncryptVersionedSymmetricWithSuite is EncryptVersionedSymmetric with a specific symmetric cipher suite
+func (m *MCrypt) EncryptVersionedSymmetricWithSuite(suite crypto.SuiteID, plaintext, associatedData []byte) ([]byte, error) {
+	m.mu.RLock()
+	secretKey := m.secretKey
+	m.mu.RUnlock()
+	if secretKey == nil {
+		return nil, ErrSecretKeyMissing
+	}
+	return crypto.Encrypt(suite, secretKey, secretKeyID(secretKey), plaintext, associatedData)
+}
+
+// EncryptVersioned encrypts payload for the recipient (box shared key of the active encryption key and
+// recipientPublicKey) into a self describing ciphertext. The header key ID is the recipient key ID
+func (m *MCrypt) EncryptVersioned(recipientPublicKey crypto.PubCKey, payload, associatedData []byte) ([]byte, error) {
+	sharedKey := m.SharedKey(recipientPublicKey)
+	return crypto.Encrypt(crypto.SuiteCurve25519XSalsa20Poly1305, sharedKey[:], encKeyID(recipientPublicKey), payload, associatedData)
+}
+
+// DecryptVersioned is the single entry point for versioned ciphertexts: algorithm and key are taken from the
+// header. Symmetric ciphertexts use the domain secret key, box ciphertexts the (active or retired) encryption
+// key with the header key ID and senderPublicKey (may be nil for symmetric ciphertexts).
+// Bare ciphertexts without the header (created before versioning) are decrypted with DecryptSymmetric when
+// senderPublicKey is nil and with Decrypt (DecryptWithAD if associatedData is set) otherwise. They are also tried
+// when a ciphertext with a header fails to decrypt, random nonces of bare ciphertexts can start with the header
+func (m *MCrypt) DecryptVersioned(senderPublicKey crypto.PubCKey, ciphertext, associatedData []byte) ([]byte, error) {
+	if !crypto.IsVersionedCiphertext(ciphertext) {
+		return m.decryptLegacy(senderPublicKey, ciphertext, associatedData)
+	}
+
+	m.mu.RLock()
+	secretKey := m.secretKey
+	encKeys := m.encKeys
+	sharedKeys := m.sharedKeys
+	m.mu.RUnlock()
+
+	plain, err := crypto.Decrypt(ciphertext, associatedData, func(suite crypto.SuiteID, keyID string) ([]byte, error) {
+		if suite == crypto.SuiteCurve25519XSalsa20Poly1305 {
+			if senderPublicKey == nil {
+				return nil, crypto.ErrDecryptionFailed
+			}
+			for _, k := range encKeys {
+				if k.ID == keyID && k.State != KeyStateRevoked {
+					sharedKey := sharedKeys.get(k.ID, k.PrivKey, senderPublicKey)
+					return sharedKey[:], nil
+				}
+			}
+			return nil, ErrKeyNotFound
+		}
+		if secretKey != nil && keyID == secretKeyID(secretKey) {
+			return secretKey, nil
+		}
+		return nil, ErrKeyNotFound
+	})
+	if err != nil && (errors.Is(err, crypto.ErrUnknownCipherSuite) || errors.Is(err, ErrKeyNotFound) || errors.Is(err, crypto.ErrDecryptionFailed)) {
+		// bare ciphertexts start with a random nonce, a few of them look like a versioned header
+		if legacy, legacyErr := m.decryptLegacy(senderPublicKey, ciphertext, associatedData); legacyErr == nil {
+			return legacy, nil
+		}
+	}
+	return plain, err
+}
+
+// decryptLegacy decrypts bare ciphertexts of EncryptSymmetric, Encrypt and EncryptWithAD
+func (m *MCrypt) decryptLegacy(senderPublicKey crypto.PubCKey, ciphertext, associatedData []byte) ([]byte, error) {
+	if senderPublicKey == nil {
+		return m.DecryptSymmetric(ciphertext, associatedData)
+	}
+	if len(associatedData) == 0 {
+		return m.Decrypt(senderPublicKey, ciphertext)
+	}
+	return m.DecryptWithAD(senderPublicKey, ciphertext, associatedData)
+}
@@ -0,0 +1,136 @@
+package mcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/igorrendulic/mcrypt-sdk-go/crypto"
+	"github.com/tj/assert"
+	"golang.org/x/crypto/nacl/box"
+)
+
+func TestVersionedEncryption(t *testing.T) {
+	alice, err := NewMCryptFromSeed("alice.io", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewMCryptFromSeed("bob.io", []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("stored value")
+
+	for _, suite := range []crypto.SuiteID{crypto.SuiteAes256GCM, crypto.SuiteXChaCha20Poly1305} {
+		encrypted, err := alice.EncryptVersionedSymmetricWithSuite(suite, msg, []byte("row-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := alice.DecryptVersioned(nil, encrypted, []byte("row-1"))
+		assert.NoError(t, err)
+		assert.Equal(t, msg, decrypted)
+
+		_, err = bob.DecryptVersioned(nil, encrypted, []byte("row-1"))
+		assert.Equal(t, ErrKeyNotFound, err)
+	}
+
+	encrypted, err := alice.EncryptVersioned(bob.EncPubKey, msg, []byte("row-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := crypto.ParseCiphertextHeader(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, bob.EncKeys()[0].ID, header.KeyID)
+
+	// key ID in the header selects the retired key after rotation
+	err = bob.RotateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := bob.DecryptVersioned(alice.EncPubKey, encrypted, []byte("row-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+
+	_, err = bob.DecryptVersioned(alice.EncPubKey, encrypted, []byte("row-2"))
+	assert.Equal(t, crypto.ErrDecryptionFailed, err)
+}
+
+func TestDecryptVersionedLegacy(t *testing.T) {
+	alice, err := NewMCryptFromSeed("alice.io", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewMCryptFromSeed("bob.io", []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("stored before versioning")
+
+	symmetric, err := alice.EncryptSymmetric(msg, []byte("row-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, crypto.IsVersionedCiphertext(symmetric))
+	decrypted, err := alice.DecryptVersioned(nil, symmetric, []byte("row-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+	_, err = alice.DecryptVersioned(nil, symmetric, []byte("row-2"))
+	assert.Equal(t, crypto.ErrDecryptionFailed, err)
+
+	box, err := alice.Encrypt(bob.EncPubKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err = bob.DecryptVersioned(alice.EncPubKey, box, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+
+	boxWithAD, err := alice.EncryptWithAD(bob.EncPubKey, msg, []byte("row-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err = bob.DecryptVersioned(alice.EncPubKey, boxWithAD, []byte("row-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+	_, err = bob.DecryptVersioned(alice.EncPubKey, boxWithAD, []byte("row-2"))
+	assert.Equal(t, crypto.ErrDecryptionFailed, err)
+}
+
+func TestDecryptVersionedLegacyWithHeaderLikeNonce(t *testing.T) {
+	alice, err := NewMCryptFromSeed("alice.io", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewMCryptFromSeed("bob.io", []byte("fedcba9876543210fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("stored before versioning")
+
+	// bare AES-GCM ciphertext whose random nonce starts with the header of an unknown suite
+	block, err := aes.NewCipher(alice.secretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := append([]byte("MCR\x01\xfe\x00"), make([]byte, gcm.NonceSize()-6)...)
+	symmetric := gcm.Seal(nonce, nonce, msg, []byte("row-1"))
+	assert.True(t, crypto.IsVersionedCiphertext(symmetric))
+	decrypted, err := alice.DecryptVersioned(nil, symmetric, []byte("row-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+	_, err = alice.DecryptVersioned(nil, symmetric, []byte("row-2"))
+	assert.Equal(t, crypto.ErrUnknownCipherSuite, err)
+
+	// bare box ciphertext whose nonce looks like a box header with an unknown key ID
+	var boxNonce [24]byte
+	copy(boxNonce[:], []byte{'M', 'C', 'R', 1, byte(crypto.SuiteCurve25519XSalsa20Poly1305), 0})
+	bare := box.Seal(boxNonce[:], msg, &boxNonce, bob.EncPubKey.Raw(), alice.EncPrivKey.Raw())
+	assert.True(t, crypto.IsVersionedCiphertext(bare))
+	decrypted, err = bob.DecryptVersioned(alice.EncPubKey, bare, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, decrypted)
+}
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 // Exists returns whether the given file or directory exists
@@ -17,10 +19,10 @@ func Exists(path string) (bool, error) {
 	return true, err
 }
 
-// creates a folder if it doesn't exist
+// creates a folder (owner only access) if it doesn't exist
 func CreateFolderIfNotExists(dataPath string) error {
 	if _, err := os.Stat(dataPath); os.IsNotExist(err) {
-		errMkDir := os.Mkdir(dataPath, 0777)
+		errMkDir := os.Mkdir(dataPath, 0700)
 		if errMkDir != nil {
 			fmt.Printf("Error creating directory: %s\n", err.Error())
 			return fmt.Errorf("Error creating directory: %s", dataPath)
@@ -28,3 +30,44 @@ func CreateFolderIfNotExists(dataPath string) error {
 	}
 	return nil
 }
+
+// WriteFileAtomic writes data to a temp file in the same folder, syncs it and renames it over filePath,
+// so readers (and crashes) never see a partially written file
+func WriteFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	dir, name := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := ioutil.TempFile(dir, "."+name+".tmp-")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	// no-op once renamed
+	defer os.Remove(tmpName)
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, filePath); err != nil {
+		return err
+	}
+	// persist the rename itself (best effort, not supported on every platform)
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}